@@ -18,11 +18,6 @@ import (
 	"time"
 )
 
-type Authorization interface {
-	// adds credentials to the url, header, and form values to permit the request.
-	Authorize(*url.URL, http.Header, url.Values) error
-}
-
 type AccessToken string
 
 // adds an Authorization header containing the access token
@@ -47,28 +42,146 @@ type ClientCredentials struct {
 
 // adds an Authorization header containing an HMAC-SHA1 signature
 func (creds *ClientCredentials) Authorize(uri *url.URL, header http.Header, values url.Values) error {
+	return creds.AuthorizeTime(time.Now(), uri, header, values)
+}
+
+// adds an Authorization header containing an HMAC-SHA1 signature for a request
+// made at time t.
+func (creds *ClientCredentials) AuthorizeTime(t time.Time, uri *url.URL, header http.Header, values url.Values) error {
+	return creds.AuthorizeEncoding(SignRaw, t, uri, header, values)
+}
+
+// like AuthorizeTime but writes values into the signature with the given
+// encoding.
+func (creds *ClientCredentials) AuthorizeEncoding(enc SignatureEncoding, t time.Time, uri *url.URL, header http.Header, values url.Values) error {
+	sig, err := enc.Sign(creds.Secret, uri.Path, t, values)
+	if err != nil {
+		return err
+	}
+	header.Set("Date", t.UTC().Format(signatureDateFormat))
+	header.Set("Authorization", fmt.Sprintf("Signature %s:%s", creds.Id, sig))
+	return nil
+}
+
+// an Authorization whose result depends on the time a request is made. a
+// Client authorizes requests with the time given by its Clock when their
+// Authorization implements TimedAuthorization.
+type TimedAuthorization interface {
+	Authorization
+	AuthorizeTime(t time.Time, uri *url.URL, header http.Header, values url.Values) error
+}
+
+// a source for the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// a function that implements Clock.
+type ClockFunc func() time.Time
+
+func (fn ClockFunc) Now() time.Time {
+	return fn()
+}
+
+// the clock used by new clients.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// the format of the Date header sent with signed requests.
+const signatureDateFormat = "2006-01-02 15:04:05"
+
+// determines how parameters are written into the string signed by
+// ClientCredentials.
+type SignatureEncoding int
+
+const (
+	// parameters are written as unescaped key=value lines, sorted. this is
+	// the canonicalization Capture verifies and the encoding used unless a
+	// client specifies otherwise.
+	SignRaw SignatureEncoding = iota
+	// keys and values are percent-encoded as in RFC 3986, leaving only
+	// unreserved characters (A-Z a-z 0-9 - . _ ~) intact, before being
+	// joined and sorted, so that parameters containing '=', newlines or
+	// non-ASCII characters cannot be confused with one another. Capture does
+	// not accept these signatures; use it only with servers that verify the
+	// same encoding, such as a Verifier with Encoding SignURLEncoded.
+	SignURLEncoded
+)
+
+func (enc SignatureEncoding) String() string {
+	switch enc {
+	case SignRaw:
+		return "raw"
+	case SignURLEncoded:
+		return "urlencoded"
+	}
+	return fmt.Sprintf("SignatureEncoding(%d)", int(enc))
+}
+
+// compute the base64 encoded HMAC-SHA1 signature of a request for path made at
+// time t with the given values.
+func (enc SignatureEncoding) Sign(secret, path string, t time.Time, values url.Values) (string, error) {
+	return enc.signature(secret, path, t.UTC().Format(signatureDateFormat), values)
+}
+
+// the string signed by enc.Sign. useful for debugging signature mismatches.
+func (enc SignatureEncoding) Base(path string, t time.Time, values url.Values) string {
+	return enc.base(path, t.UTC().Format(signatureDateFormat), values)
+}
+
+// compute the signature of a request using SignRaw.
+func Sign(secret, path string, t time.Time, values url.Values) (string, error) {
+	return SignRaw.Sign(secret, path, t, values)
+}
+
+// the string signed by Sign.
+func SignatureBase(path string, t time.Time, values url.Values) string {
+	return SignRaw.Base(path, t, values)
+}
+
+func (enc SignatureEncoding) base(path, timestamp string, values url.Values) string {
+	escape := func(s string) string { return s }
+	if enc == SignURLEncoded {
+		escape = percentEncode
+	}
 	ps := make([]string, 0, len(values))
 	for k := range values {
 		for _, v := range values[k] {
-			param := fmt.Sprintf("%s=%s", k, v) // not currently url encoded
+			param := fmt.Sprintf("%s=%s", escape(k), escape(v))
 			ps = append(ps, param)
 		}
 	}
 	sort.Strings(ps)
-	timestamp := time.Now().UTC().Format("2006-01-02 15:04:05")
 	tosign := new(bytes.Buffer)
-	fmt.Fprintln(tosign, uri.Path)
+	fmt.Fprintln(tosign, path)
 	fmt.Fprintln(tosign, timestamp)
 	for _, p := range ps {
 		fmt.Fprintln(tosign, p)
 	}
-	hash := hmac.New(sha1.New, []byte(creds.Secret))
-	_, err := hash.Write(tosign.Bytes())
+	return tosign.String()
+}
+
+func (enc SignatureEncoding) signature(secret, path, timestamp string, values url.Values) (string, error) {
+	hash := hmac.New(sha1.New, []byte(secret))
+	_, err := hash.Write([]byte(enc.base(path, timestamp, values)))
 	if err != nil {
-		return err
+		return "", err
 	}
-	sig := base64.StdEncoding.EncodeToString(hash.Sum(nil))
-	header.Set("Date", timestamp)
-	header.Set("Authorization", fmt.Sprintf("Signature %s:%s", creds.Id, sig))
-	return nil
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// percent-encode every byte of s other than RFC 3986 unreserved characters.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			buf = append(buf, c)
+		default:
+			buf = append(buf, '%', hex[c>>4], hex[c&0xf])
+		}
+	}
+	return string(buf)
 }
@@ -0,0 +1,263 @@
+package capture
+
+import (
+	"github.com/bmatsuo1/go-janrain"
+
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func prepare(uri *url.URL, header http.Header, values url.Values) (*http.Request, error) {
+	req := &janrain.Request{Verb: "POST", URL: uri, Header: header, Values: values}
+	return req.HttpRequest()
+}
+
+func TestVerifySignature(t *testing.T) {
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	lookup := func(id string) (string, bool) {
+		if id == creds.Id {
+			return creds.Secret, true
+		}
+		return "", false
+	}
+	uri, _ := url.Parse("https://myapp.janraincapture.com/entity.count")
+	values := url.Values{"type_name": {"user"}}
+	header := make(http.Header)
+	err := creds.Authorize(uri, header, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := prepare(uri, header, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySignature(req, lookup, time.Minute)
+	if err != nil {
+		t.Errorf("unexpected verification error: %v", err)
+	}
+
+	req, _ = prepare(uri, header, url.Values{"type_name": {"admin"}})
+	err = VerifySignature(req, lookup, time.Minute)
+	if _, ok := err.(SignatureError); !ok {
+		t.Errorf("unexpected error for tampered params: %v", err)
+	}
+
+	req, _ = prepare(uri, header, values)
+	req.Header.Set("Authorization", "Signature someoneelse:"+req.Header.Get("Authorization")[len("Signature myclientid:"):])
+	err = VerifySignature(req, lookup, time.Minute)
+	if _, ok := err.(UnknownClientError); !ok {
+		t.Errorf("unexpected error for unknown client: %v", err)
+	}
+
+	req, _ = prepare(uri, header, values)
+	req.Header.Set("Date", time.Now().UTC().Add(-time.Hour).Format(signatureDateFormat))
+	err = VerifySignature(req, lookup, time.Minute)
+	if _, ok := err.(ClockSkewError); !ok {
+		t.Errorf("unexpected error for skewed date: %v", err)
+	}
+}
+
+func TestVerifierClock(t *testing.T) {
+	date := time.Date(2013, 5, 21, 12, 0, 0, 0, time.UTC)
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	uri, _ := url.Parse("https://myapp.janraincapture.com/entity.count")
+	values := url.Values{"type_name": {"user"}}
+	header := make(http.Header)
+	err := creds.AuthorizeTime(date, uri, header, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var now time.Time
+	v := &Verifier{
+		Lookup: func(string) (string, bool) { return creds.Secret, true },
+		Skew:   time.Minute,
+		Clock:  ClockFunc(func() time.Time { return now }),
+	}
+	for _, test := range []struct {
+		now  time.Time
+		skew bool
+	}{
+		{date, false},
+		{date.Add(time.Minute), false},
+		{date.Add(-time.Minute), false},
+		{date.Add(time.Minute + time.Second), true},
+		{date.Add(-time.Minute - time.Second), true},
+	} {
+		now = test.now
+		req, _ := prepare(uri, header, values)
+		err := v.Verify(req)
+		if _, skew := err.(ClockSkewError); skew != test.skew || (!skew && err != nil) {
+			t.Errorf("unexpected error at %v: %v", test.now, err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	date := time.Date(2013, 5, 21, 12, 0, 0, 0, time.UTC)
+	values := url.Values{
+		"type_name": {"user"},
+		"filter":    {"displayName = 'chareth'"},
+	}
+	base := SignatureBase("/entity.count", date, values)
+	if base != "/entity.count\n2013-05-21 12:00:00\nfilter=displayName = 'chareth'\ntype_name=user\n" {
+		t.Errorf("unexpected signature base: %q", base)
+	}
+	sig, err := Sign("myclientsecret", "/entity.count", date, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != "n8sK8wp+5V3e8usixuqUBQZIL8U=" {
+		t.Errorf("unexpected signature: %q", sig)
+	}
+
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	client := NewClient("https://myapp.janraincapture.com", creds)
+	client.SetClock(ClockFunc(func() time.Time { return date }))
+	req, err := client.request(creds, "POST", "/entity.count", nil, Params{
+		"type_name": "user",
+		"filter":    "displayName = 'chareth'",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Signature myclientid:"+sig {
+		t.Errorf("unexpected authorization header: %q", auth)
+	}
+	if d := req.Header.Get("Date"); d != "2013-05-21 12:00:00" {
+		t.Errorf("unexpected date header: %q", d)
+	}
+}
+
+// parameters are signed unescaped, including JSON values containing '=',
+// newlines and non-ASCII characters.
+func TestSignJSONParams(t *testing.T) {
+	date := time.Date(2013, 5, 21, 12, 0, 0, 0, time.UTC)
+	params := Params{
+		"type_name": "user",
+		"attributes": map[string]interface{}{
+			"givenName": "Zoë",
+			"aboutMe":   "x=1\ny",
+		},
+	}
+	values, err := params.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := SignatureBase("/entity.update", date, values)
+	expect := "/entity.update\n2013-05-21 12:00:00\n" +
+		"attributes={\"aboutMe\":\"x=1\\ny\",\"givenName\":\"Zoë\"}\n" +
+		"type_name=user\n"
+	if base != expect {
+		t.Errorf("unexpected signature base: %q", base)
+	}
+	sig, err := Sign("myclientsecret", "/entity.update", date, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != "p3Ncq7F1KSFgVLgMPtYAOkiuiT0=" {
+		t.Errorf("unexpected signature: %q", sig)
+	}
+
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	client := NewClient("https://myapp.janraincapture.com", creds)
+	client.SetClock(ClockFunc(func() time.Time { return date }))
+	req, err := client.request(creds, "POST", "/entity.update", nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Signature myclientid:"+sig {
+		t.Errorf("unexpected authorization header: %q", auth)
+	}
+	hreq, err := req.HttpRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(string) (string, bool) { return creds.Secret, true }
+	err = VerifySignature(hreq, lookup, 0)
+	if err != nil {
+		t.Errorf("unexpected verification error: %v", err)
+	}
+}
+
+// a client may sign with SignURLEncoded, and only a Verifier expecting that
+// encoding accepts its signatures.
+func TestSignURLEncoded(t *testing.T) {
+	date := time.Date(2013, 5, 21, 12, 0, 0, 0, time.UTC)
+	params := Params{
+		"type_name": "user",
+		"attributes": map[string]interface{}{
+			"givenName": "Zoë",
+			"aboutMe":   "x=1\ny",
+		},
+	}
+	values, err := params.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := SignURLEncoded.Base("/entity.update", date, values)
+	expect := "/entity.update\n2013-05-21 12:00:00\n" +
+		"attributes=%7B%22aboutMe%22%3A%22x%3D1%5Cny%22%2C%22givenName%22%3A%22Zo%C3%AB%22%7D\n" +
+		"type_name=user\n"
+	if base != expect {
+		t.Errorf("unexpected signature base: %q", base)
+	}
+	sig, err := SignURLEncoded.Sign("myclientsecret", "/entity.update", date, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != "TeW6Oe1AmkMQ6/2FpIBOTPrV8ro=" {
+		t.Errorf("unexpected signature: %q", sig)
+	}
+
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	client := NewClient("https://myapp.janraincapture.com", creds)
+	client.SetClock(ClockFunc(func() time.Time { return date }))
+	client.SetSignatureEncoding(SignURLEncoded)
+	req, err := client.request(creds, "POST", "/entity.update", nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Signature myclientid:"+sig {
+		t.Errorf("unexpected authorization header: %q", auth)
+	}
+	lookup := func(string) (string, bool) { return creds.Secret, true }
+	for _, enc := range []SignatureEncoding{SignRaw, SignURLEncoded} {
+		hreq, err := req.HttpRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = (&Verifier{Lookup: lookup, Encoding: enc}).Verify(hreq)
+		if enc == SignURLEncoded && err != nil {
+			t.Errorf("%v: unexpected verification error: %v", enc, err)
+		}
+		if _, ok := err.(SignatureError); enc == SignRaw && !ok {
+			t.Errorf("%v: unexpected verification result: %v", enc, err)
+		}
+	}
+}
+
+func TestVerifySignatureGet(t *testing.T) {
+	creds := &ClientCredentials{"myclientid", "myclientsecret"}
+	client := NewClient("https://myapp.janraincapture.com", creds)
+	req, err := client.request(creds, "GET", "/entity", nil, Params{
+		"type_name": "user",
+		"uuid":      "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hreq, err := req.HttpRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hreq.Body != nil || hreq.URL.Query().Get("uuid") != "abc" {
+		t.Errorf("unexpected GET request: %v", hreq.URL)
+	}
+	lookup := func(string) (string, bool) { return creds.Secret, true }
+	err = VerifySignature(hreq, lookup, time.Minute)
+	if err != nil {
+		t.Errorf("unexpected verification error: %v", err)
+	}
+}
@@ -0,0 +1,443 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// export.go [created: Sun, 18 Oct 2026]
+
+/*
+Package bulk moves large numbers of Capture entities in and out of an app.
+
+Export walks /entity.find in order of id, writing entities as newline delimited
+JSON or CSV. When given a checkpoint file an export records its progress after
+each page and resumes from it when restarted.
+
+	out, _ := os.OpenFile("users.csv", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	cp, err := bulk.Export(client, out, bulk.ExportOptions{
+		TypeName:   "user",
+		Filter:     filter.New("emailVerified is not", nil),
+		Attributes: []string{"uuid", "email", "primaryAddress"},
+		Format:     bulk.CSV,
+		Checkpoint: "users.checkpoint",
+	})
+
+Import creates entities with /entity.bulkCreate. Records are validated against
+the entity type's schema before they are sent and the outcome of each record is
+written to a results file. Records that fail are also written as NDJSON to a
+file of their own, which can be corrected and imported again.
+
+	summary, err := bulk.Import(client, in, bulk.ImportOptions{
+		TypeName:    "user",
+		Format:      bulk.CSV,
+		Mapping:     map[string]string{"mail": "email", "city": "primaryAddress.city"},
+		Concurrency: 4,
+		Results:     results,
+		Failed:      failed,
+	})
+
+Update applies a Transform to every entity matching a filter, calling
+/entity.update for those it changes. Changes are computed with capture.Diff;
+plural elements the Transform leaves out are kept unless DeleteElements is set.
+A dry run writes the changes that would be made without making them.
+
+	summary, err := bulk.Update(client, bulk.UpdateOptions{
+		TypeName: "user",
+		Filter:   filter.New("email like", "%@EXAMPLE.COM"),
+		Transform: func(user map[string]interface{}) (map[string]interface{}, error) {
+			email, _ := user["email"].(string)
+			return map[string]interface{}{"email": strings.ToLower(email)}, nil
+		},
+		Concurrency: 4,
+		Rate:        10,
+		DryRun:      true,
+		Diff:        os.Stdout,
+		Log:         "lowercase-email.log",
+	})
+*/
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+	"github.com/bmatsuo1/go-janrain/capture/filter"
+
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the number of entities requested per page when none is given.
+var DefaultPageSize = 1000
+
+// an output format.
+type Format int
+
+const (
+	NDJSON Format = iota // one JSON object per line
+	CSV                  // a header row and one row per entity
+)
+
+// how plurals (arrays of objects) are written.
+type PluralMode int
+
+const (
+	// write each plural as a single JSON value.
+	PluralJSON PluralMode = iota
+	// flatten plural elements into columns named by index, e.g.
+	// "profiles.0.domain". NDJSON output is written as with PluralJSON.
+	PluralIndexed
+	// omit plurals.
+	PluralSkip
+)
+
+// the progress of an export, as stored in a checkpoint file.
+type Checkpoint struct {
+	LastId  int64    `json:"last_id"`
+	Count   int      `json:"count"`
+	Columns []string `json:"columns,omitempty"` // CSV columns
+}
+
+// read a checkpoint file. a missing file is an empty checkpoint.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	cp := new(Checkpoint)
+	p, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(p, cp)
+	if err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// write a checkpoint file, replacing any previous checkpoint atomically.
+func WriteCheckpoint(path string, cp *Checkpoint) error {
+	p, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, p, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type ExportOptions struct {
+	TypeName string
+	Filter   filter.Interface // nil exports all entities
+
+	// the attributes to export. nil exports all attributes.
+	Attributes []string
+
+	// entities requested per call to /entity.find. if zero DefaultPageSize is
+	// used.
+	PageSize int
+
+	Format  Format
+	Plurals PluralMode
+
+	// CSV columns, as flattened attribute paths. if nil the columns are the
+	// attributes of the first page of entities, sorted, and the first page is
+	// held in memory until they are known. values of other attributes are
+	// dropped.
+	Columns []string
+
+	// the path of a checkpoint file, written after each page. if the file
+	// exists the export resumes after the last entity it records. output
+	// should be appended to the output of the interrupted export; entities
+	// written after the last checkpoint, at most a page, are written again.
+	Checkpoint string
+}
+
+// write entities to w, returning the progress made. the returned checkpoint
+// is valid even if an error is returned.
+func Export(client *capture.Client, w io.Writer, opts ExportOptions) (*Checkpoint, error) {
+	cp := new(Checkpoint)
+	if opts.Checkpoint != "" {
+		var err error
+		cp, err = ReadCheckpoint(opts.Checkpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if cp.Columns == nil {
+		cp.Columns = opts.Columns
+	}
+
+	var csvw *csv.Writer
+	if opts.Format == CSV {
+		csvw = csv.NewWriter(w)
+	}
+	header := cp.Count == 0
+	writeRow := func(row map[string]interface{}) error {
+		if header {
+			header = false
+			err := csvw.Write(cp.Columns)
+			if err != nil {
+				return err
+			}
+		}
+		return writeCSV(csvw, cp.Columns, row)
+	}
+	var pending []map[string]interface{} // CSV rows written once columns are known
+	count := 0                           // entities written since the last checkpoint
+	write := func(entity map[string]interface{}, _ int64) error {
+		count++
+		if csvw == nil {
+			return writeNDJSON(w, entity, opts.Plurals)
+		}
+		row := Flatten(entity, opts.Plurals)
+		if cp.Columns == nil {
+			pending = append(pending, row)
+			return nil
+		}
+		return writeRow(row)
+	}
+	checkpoint := func(lastId int64) error {
+		if csvw != nil {
+			if pending != nil {
+				cp.Columns = columns(pending)
+				for _, row := range pending {
+					err := writeRow(row)
+					if err != nil {
+						return err
+					}
+				}
+				pending = nil
+			}
+			csvw.Flush()
+			err := csvw.Error()
+			if err != nil {
+				return err
+			}
+		}
+		cp.LastId = lastId
+		cp.Count += count
+		count = 0
+		if opts.Checkpoint != "" {
+			return WriteCheckpoint(opts.Checkpoint, cp)
+		}
+		return nil
+	}
+	err := walk(client, opts.TypeName, opts.Filter, opts.Attributes, pageSize, cp.LastId, write, checkpoint)
+	return cp, err
+}
+
+// pass the entities matching f with ids greater than lastId to fn in order of
+// id, as they are received. end, if not nil, is called after each page with
+// the id of its last entity. if attrs is not nil only the named attributes are
+// retrieved.
+func walk(client *capture.Client, typeName string, f filter.Interface, attrs []string, pageSize int, lastId int64, fn func(entity map[string]interface{}, id int64) error, end func(lastId int64) error) error {
+	params := capture.Params{
+		"sort_on":     []string{"id"},
+		"max_results": pageSize,
+	}
+	var keepId bool
+	if attrs != nil {
+		attrs = append([]string(nil), attrs...)
+		keepId = contains(attrs, "id")
+		if !keepId {
+			// paging requires ids.
+			attrs = append(attrs, "id")
+		}
+		params["attributes"] = attrs
+	}
+	for {
+		params["filter"] = after(lastId, f).Filter()
+		n := 0
+		_, err := client.EntityFindEach(typeName, params, func(raw json.RawMessage) error {
+			entity, err := decodeEntity(raw)
+			if err != nil {
+				return err
+			}
+			id, err := entityId(entity)
+			if err != nil {
+				return err
+			}
+			if attrs != nil && !keepId {
+				delete(entity, "id")
+			}
+			n++
+			lastId = id
+			return fn(entity, id)
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if end != nil {
+			err = end(lastId)
+			if err != nil {
+				return err
+			}
+		}
+		if n < pageSize {
+			return nil
+		}
+	}
+}
+
+// a filter for entities after lastId that match f.
+func after(lastId int64, f filter.Interface) filter.Interface {
+	byId := filter.New("id >", lastId)
+	if f == nil || f.Filter() == "" {
+		return byId
+	}
+	return filter.And(byId, f)
+}
+
+func writeNDJSON(w io.Writer, entity map[string]interface{}, plurals PluralMode) error {
+	if plurals == PluralSkip {
+		skipPlurals(entity)
+	}
+	p, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(p, '\n'))
+	return err
+}
+
+// write the columns of a flattened entity.
+func writeCSV(w *csv.Writer, columns []string, row map[string]interface{}) error {
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = formatValue(row[col])
+	}
+	return w.Write(record)
+}
+
+// flatten nested objects in entity into attribute paths joined by ".". plurals
+// are handled according to mode.
+func Flatten(entity map[string]interface{}, plurals PluralMode) map[string]interface{} {
+	flat := make(map[string]interface{})
+	flatten(flat, "", entity, plurals)
+	return flat
+}
+
+func flatten(flat map[string]interface{}, prefix string, v interface{}, plurals PluralMode) {
+	key := strings.TrimSuffix(prefix, ".")
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && key != "" {
+			flat[key] = nil
+		}
+		for k, elem := range v {
+			flatten(flat, prefix+k+".", elem, plurals)
+		}
+	case []interface{}:
+		switch {
+		case !isPlural(v) || plurals == PluralJSON:
+			flat[key] = v
+		case plurals == PluralIndexed:
+			for i, elem := range v {
+				flatten(flat, fmt.Sprintf("%s%d.", prefix, i), elem, plurals)
+			}
+		}
+	default:
+		flat[key] = v
+	}
+}
+
+// true if v is an array of objects.
+func isPlural(v interface{}) bool {
+	elems, ok := v.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range elems {
+		if _, ok := elem.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// remove plurals from entity at any depth.
+func skipPlurals(entity map[string]interface{}) {
+	for k, v := range entity {
+		if m, ok := v.(map[string]interface{}); ok {
+			skipPlurals(m)
+		} else if _, ok := v.([]interface{}); ok && isPlural(v) {
+			delete(entity, k)
+		}
+	}
+}
+
+// the sorted union of the keys of rows.
+func columns(rows []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, row := range rows {
+		for k := range row {
+			if !seen[k] {
+				seen[k] = true
+				cols = append(cols, k)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// the text of a CSV field.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	p, _ := json.Marshal(v)
+	return string(p)
+}
+
+// decode an entity, keeping numbers exact.
+func decodeEntity(raw json.RawMessage) (map[string]interface{}, error) {
+	var entity map[string]interface{}
+	err := decodeJSON(raw, &entity)
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func entityId(entity map[string]interface{}) (int64, error) {
+	switch id := entity["id"].(type) {
+	case json.Number:
+		return id.Int64()
+	case nil:
+		return 0, fmt.Errorf("entity has no id")
+	default:
+		return 0, fmt.Errorf("unexpected id %v", id)
+	}
+}
+
+func contains(strs []string, s string) bool {
+	for _, _s := range strs {
+		if _s == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serves /entity.find over entities with ids 1 through n.
+func findServer(t *testing.T, n int, fail func(lastId int) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var lastId int
+		_, err := fmt.Sscanf(r.FormValue("filter"), "id > %d", &lastId)
+		if err != nil {
+			t.Errorf("unexpected filter: %q", r.FormValue("filter"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if fail != nil && fail(lastId) {
+			fmt.Fprint(w, `{"stat":"error","code":510,"error":"rate_limit_exceeded"}`)
+			return
+		}
+		max, _ := strconv.Atoi(r.FormValue("max_results"))
+		var results []interface{}
+		for id := lastId + 1; id <= n && len(results) < max; id++ {
+			results = append(results, map[string]interface{}{
+				"id":      id,
+				"email":   fmt.Sprintf("%d@example.com", id),
+				"address": map[string]interface{}{"city": "Portland"},
+				"profiles": []interface{}{
+					map[string]interface{}{"domain": "example.com"},
+				},
+			})
+		}
+		p, _ := json.Marshal(map[string]interface{}{
+			"stat":         "ok",
+			"result_count": len(results),
+			"results":      results,
+		})
+		w.Write(p)
+	}))
+}
+
+func TestExportCSV(t *testing.T) {
+	server := findServer(t, 3, nil)
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	var buf bytes.Buffer
+	cp, err := Export(client, &buf, ExportOptions{
+		TypeName: "user",
+		PageSize: 2,
+		Format:   CSV,
+		Plurals:  PluralIndexed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Count != 3 || cp.LastId != 3 {
+		t.Errorf("unexpected checkpoint: %#v", cp)
+	}
+	expect := "address.city,email,id,profiles.0.domain\n" +
+		"Portland,1@example.com,1,example.com\n" +
+		"Portland,2@example.com,2,example.com\n" +
+		"Portland,3@example.com,3,example.com\n"
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestExportResume(t *testing.T) {
+	failing := true
+	server := findServer(t, 5, func(lastId int) bool { return failing && lastId >= 2 })
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+	opts := ExportOptions{
+		TypeName:   "user",
+		Attributes: []string{"email"},
+		PageSize:   2,
+		Plurals:    PluralSkip,
+		Checkpoint: filepath.Join(t.TempDir(), "checkpoint"),
+	}
+
+	var buf bytes.Buffer
+	_, err := Export(client, &buf, opts)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	failing = false
+	cp, err := Export(client, &buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Count != 5 || cp.LastId != 5 {
+		t.Errorf("unexpected checkpoint: %#v", cp)
+	}
+	var expect string
+	for id := 1; id <= 5; id++ {
+		expect += fmt.Sprintf(`{"address":{"city":"Portland"},"email":"%d@example.com"}`+"\n", id)
+	}
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
+
+// entities are written as they are received rather than a page at a time.
+func TestExportStream(t *testing.T) {
+	written := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("filter") != "id > 0" {
+			fmt.Fprint(w, `{"stat":"ok","result_count":0,"results":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"stat":"ok","results":[{"id":1,"email":"1@example.com"},`)
+		w.(http.Flusher).Flush()
+		select {
+		case <-written:
+		case <-time.After(5 * time.Second):
+			t.Error("entity was not written before the page ended")
+		}
+		fmt.Fprint(w, `{"id":2,"email":"2@example.com"}],"result_count":2}`)
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	var buf bytes.Buffer
+	once := new(sync.Once)
+	cp, err := Export(client, writerFunc(func(p []byte) (int, error) {
+		once.Do(func() { close(written) })
+		return buf.Write(p)
+	}), ExportOptions{TypeName: "user", PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Count != 2 || cp.LastId != 2 {
+		t.Errorf("unexpected checkpoint: %#v", cp)
+	}
+	expect := `{"email":"1@example.com","id":1}` + "\n" + `{"email":"2@example.com","id":2}` + "\n"
+	if buf.String() != expect {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// import.go [created: Sun, 18 Oct 2026]
+
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// the number of entities sent per call to /entity.bulkCreate when none is
+// given.
+var DefaultBatchSize = 100
+
+type ImportOptions struct {
+	TypeName string
+	Format   Format
+
+	// maps CSV columns, or the top-level keys of NDJSON records, to dot
+	// separated attribute paths (e.g. "city" -> "primaryAddress.city"). if
+	// nil columns are used as attribute paths. when not nil, columns missing
+	// from the mapping are ignored.
+	Mapping map[string]string
+
+	// entities sent per call to /entity.bulkCreate. if zero DefaultBatchSize
+	// is used.
+	BatchSize int
+
+	// the number of concurrent calls to /entity.bulkCreate. if zero one call
+	// is made at a time.
+	Concurrency int
+
+	// the schema records are validated against. if nil the schema is
+	// retrieved with FetchSchema unless NoValidate is true. CSV fields are
+	// converted to the types of their attributes.
+	Schema     *Schema
+	NoValidate bool
+
+	// receives one ImportResult per record as newline delimited JSON. results
+	// of concurrent batches are interleaved.
+	Results io.Writer
+
+	// receives each record that could not be imported, as it was read. CSV
+	// records follow a copy of the input's header. the output can be corrected
+	// and imported again with the same options.
+	Failed io.Writer
+}
+
+// the outcome of importing a record.
+type ImportResult struct {
+	Record    int                    `json:"record"` // the 1-based position of the record in the input
+	Uuid      string                 `json:"uuid,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Code      int                    `json:"code,omitempty"`       // see capture.RemoteError
+	Kind      string                 `json:"kind,omitempty"`       // see capture.RemoteError
+	RequestId string                 `json:"request_id,omitempty"` // see capture.RemoteError
+	Entity    map[string]interface{} `json:"entity,omitempty"`     // the record as sent, on failure
+}
+
+// counts of the records processed by Import.
+type ImportSummary struct {
+	Records int
+	Created int
+	Failed  int
+}
+
+// a record to import.
+type record struct {
+	n      int
+	entity map[string]interface{}
+	line   []byte   // the NDJSON input
+	header []string // the CSV input
+	fields []string
+}
+
+// create entities from records read from r. records that fail validation are
+// not sent. the returned error is non-nil only if records could not be read or
+// results could not be written; errors creating entities are reported in
+// results.
+func Import(client *capture.Client, r io.Reader, opts ImportOptions) (*ImportSummary, error) {
+	schema := opts.Schema
+	if schema == nil && !opts.NoValidate {
+		var err error
+		schema, err = FetchSchema(client, opts.TypeName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	imp := &importer{client: client, opts: opts, summary: new(ImportSummary)}
+	batches := make(chan []record)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				imp.create(batch)
+			}
+		}()
+	}
+
+	var batch []record
+	err := readRecords(r, opts.Format, schema, opts.Mapping, func(rec record) {
+		imp.mut.Lock()
+		imp.summary.Records++
+		imp.mut.Unlock()
+		if schema != nil && !opts.NoValidate {
+			if errs := schema.Validate(rec.entity); len(errs) > 0 {
+				msgs := make([]string, len(errs))
+				for i, err := range errs {
+					msgs[i] = err.Error()
+				}
+				imp.report(rec, ImportResult{
+					Record: rec.n,
+					Error:  "invalid record: " + strings.Join(msgs, "; "),
+					Entity: rec.entity,
+				})
+				return
+			}
+		}
+		batch = append(batch, rec)
+		if len(batch) == batchSize {
+			batches <- batch
+			batch = nil
+		}
+	})
+	if len(batch) > 0 {
+		batches <- batch
+	}
+	close(batches)
+	wg.Wait()
+	if err != nil {
+		return imp.summary, err
+	}
+	return imp.summary, imp.err
+}
+
+type importer struct {
+	client *capture.Client
+	opts   ImportOptions
+
+	mut     sync.Mutex
+	summary *ImportSummary
+	failed  *csv.Writer // nil until a failed CSV record is written
+	err     error       // the first error writing results or failed records
+}
+
+// create a batch of entities and report the result of each.
+func (imp *importer) create(batch []record) {
+	entities := make([]map[string]interface{}, len(batch))
+	for i, rec := range batch {
+		entities[i] = rec.entity
+	}
+	var resp struct {
+		UuidResults []json.RawMessage `json:"uuid_results"`
+	}
+	err := imp.client.ExecuteInto("/entity.bulkCreate", nil, capture.Params{
+		"type_name":      imp.opts.TypeName,
+		"all_attributes": entities,
+	}, &resp)
+	for i, rec := range batch {
+		result := ImportResult{Record: rec.n}
+		switch {
+		case err != nil:
+			setError(&result, err)
+		case i >= len(resp.UuidResults):
+			result.Error = "no result returned"
+		default:
+			parseUuidResult(&result, resp.UuidResults[i])
+		}
+		if result.Uuid == "" {
+			result.Entity = rec.entity
+		}
+		imp.report(rec, result)
+	}
+}
+
+// a uuid, or an error object for a record that could not be created.
+func parseUuidResult(result *ImportResult, raw json.RawMessage) {
+	if json.Unmarshal(raw, &result.Uuid) == nil {
+		return
+	}
+	var remote struct {
+		Code        int    `json:"code"`
+		Kind        string `json:"error"`
+		Description string `json:"error_description"`
+		RequestId   string `json:"request_id"`
+	}
+	err := json.Unmarshal(raw, &remote)
+	if err != nil {
+		result.Error = fmt.Sprintf("unexpected result %s", raw)
+		return
+	}
+	result.Code = remote.Code
+	result.Kind = remote.Kind
+	result.RequestId = remote.RequestId
+	result.Error = fmt.Sprintf("[%s] %s", remote.Kind, remote.Description)
+}
+
+func setError(result *ImportResult, err error) {
+	result.Error = err.Error()
+	var remote capture.RemoteError
+	if errors.As(err, &remote) {
+		result.Code = remote.Code
+		result.Kind = remote.Kind
+		result.RequestId = remote.RequestId
+	}
+}
+
+func (imp *importer) report(rec record, result ImportResult) {
+	imp.mut.Lock()
+	defer imp.mut.Unlock()
+	if result.Uuid != "" {
+		imp.summary.Created++
+	} else {
+		imp.summary.Failed++
+		if imp.opts.Failed != nil && imp.err == nil {
+			imp.err = imp.writeFailed(rec)
+		}
+	}
+	if imp.opts.Results != nil && imp.err == nil {
+		imp.err = writeJSONLine(imp.opts.Results, result)
+	}
+}
+
+// must be called with imp.mut held.
+func (imp *importer) writeFailed(rec record) error {
+	if rec.fields == nil {
+		_, err := imp.opts.Failed.Write(append(rec.line, '\n'))
+		return err
+	}
+	if imp.failed == nil {
+		imp.failed = csv.NewWriter(imp.opts.Failed)
+		imp.failed.Write(rec.header)
+	}
+	imp.failed.Write(rec.fields)
+	imp.failed.Flush()
+	return imp.failed.Error()
+}
+
+func writeJSONLine(w io.Writer, v interface{}) error {
+	p, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(p, '\n'))
+	return err
+}
+
+// read records from r, passing each to fn.
+func readRecords(r io.Reader, format Format, schema *Schema, mapping map[string]string, fn func(record)) error {
+	if format == CSV {
+		return readCSV(r, schema, mapping, fn)
+	}
+	return readNDJSON(r, mapping, fn)
+}
+
+func readNDJSON(r io.Reader, mapping map[string]string, fn func(record)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	n := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		n++
+		var obj map[string]interface{}
+		err := decodeJSON(line, &obj)
+		if err != nil {
+			return fmt.Errorf("record %d: %v", n, err)
+		}
+		entity := make(map[string]interface{})
+		for k, v := range obj {
+			if path, ok := attrPath(mapping, k); ok {
+				setPath(entity, path, v)
+			}
+		}
+		fn(record{n: n, entity: entity, line: append([]byte(nil), line...)})
+	}
+	return scanner.Err()
+}
+
+func readCSV(r io.Reader, schema *Schema, mapping map[string]string, fn func(record)) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for n := 1; ; n++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %v", n, err)
+		}
+		entity := make(map[string]interface{})
+		for i, text := range fields {
+			path, ok := attrPath(mapping, header[i])
+			if !ok || text == "" {
+				continue
+			}
+			var v interface{} = text
+			if schema != nil {
+				v = schema.convert(path, text)
+			}
+			setPath(entity, path, v)
+		}
+		fn(record{n: n, entity: entity, header: header, fields: fields})
+	}
+}
+
+// the attribute path for a column. ok is false if the column is not mapped.
+func attrPath(mapping map[string]string, column string) (path string, ok bool) {
+	if mapping == nil {
+		return column, true
+	}
+	path, ok = mapping[column]
+	return path, ok
+}
+
+// set the value at a dot separated path, creating objects as needed.
+func setPath(entity map[string]interface{}, path string, v interface{}) {
+	names := strings.Split(path, ".")
+	obj := entity
+	for _, name := range names[:len(names)-1] {
+		child, ok := obj[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			obj[name] = child
+		}
+		obj = child
+	}
+	obj[names[len(names)-1]] = v
+}
+
+// decode JSON, keeping numbers exact.
+func decodeJSON(p []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,261 @@
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testSchema = &Schema{
+	Name: "user",
+	AttrDefs: []*AttrDef{
+		{Name: "email", Type: "string", Length: 20, Constraints: []string{"required"}},
+		{Name: "age", Type: "integer"},
+		{Name: "primaryAddress", Type: "object", AttrDefs: []*AttrDef{
+			{Name: "city", Type: "string"},
+		}},
+	},
+}
+
+func TestImportCSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entities []map[string]interface{}
+		err := json.Unmarshal([]byte(r.FormValue("all_attributes")), &entities)
+		if err != nil {
+			t.Error(err)
+		}
+		var results []interface{}
+		for _, entity := range entities {
+			if entity["email"] == "dup@example.com" {
+				results = append(results, map[string]interface{}{
+					"stat": "error", "code": 360, "error": "unique_violation", "error_description": "email is taken",
+				})
+				continue
+			}
+			if _, ok := entity["age"].(float64); !ok {
+				t.Errorf("age was not converted: %#v", entity)
+			}
+			results = append(results, fmt.Sprintf("uuid-%v", entity["age"]))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"stat": "ok", "uuid_results": results})
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	input := "mail,years,city,ignored\n" +
+		"a@example.com,30,Portland,x\n" +
+		"dup@example.com,31,,x\n" +
+		"far-too-long@example.com,32,,x\n" +
+		"b@example.com,33,,x\n"
+	var results bytes.Buffer
+	summary, err := Import(client, strings.NewReader(input), ImportOptions{
+		TypeName:    "user",
+		Format:      CSV,
+		Mapping:     map[string]string{"mail": "email", "years": "age", "city": "primaryAddress.city"},
+		BatchSize:   2,
+		Concurrency: 2,
+		Schema:      testSchema,
+		Results:     &results,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Records != 4 || summary.Created != 2 || summary.Failed != 2 {
+		t.Errorf("unexpected summary: %#v", summary)
+	}
+
+	byRecord := make(map[int]ImportResult)
+	for _, line := range strings.Split(strings.TrimSpace(results.String()), "\n") {
+		var result ImportResult
+		err := json.Unmarshal([]byte(line), &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byRecord[result.Record] = result
+	}
+	if byRecord[1].Uuid != "uuid-30" || byRecord[4].Uuid != "uuid-33" {
+		t.Errorf("unexpected results: %#v", byRecord)
+	}
+	if byRecord[2].Kind != "unique_violation" || byRecord[2].Entity["email"] != "dup@example.com" {
+		t.Errorf("unexpected result for duplicate: %#v", byRecord[2])
+	}
+	if !strings.Contains(byRecord[3].Error, "email: longer than 20") {
+		t.Errorf("unexpected result for invalid record: %#v", byRecord[3])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	errs := testSchema.Validate(map[string]interface{}{
+		"age":            "old",
+		"nickname":       "bob",
+		"primaryAddress": map[string]interface{}{"city": 7},
+	})
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, "\n")
+	for _, expect := range []string{
+		"age: expected integer, got string",
+		"nickname: unknown attribute",
+		"primaryAddress.city: expected string, got int",
+		"email: required",
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("missing %q in:\n%s", expect, got)
+		}
+	}
+}
+
+func TestConvertNumbers(t *testing.T) {
+	schema := &Schema{
+		Name: "user",
+		AttrDefs: []*AttrDef{
+			{Name: "age", Type: "integer"},
+			{Name: "height", Type: "decimal"},
+		},
+	}
+	for text, number := range map[string]bool{
+		"30":     true,
+		"-1.5e3": true,
+		"NaN":    false,
+		"Inf":    false,
+		"0x1p-2": false,
+		"+1":     false,
+		" 1":     false,
+		"1 2":    false,
+	} {
+		v := schema.convert("height", text)
+		if _, ok := v.(json.Number); ok != number {
+			t.Errorf("%q: unexpected conversion: %#v", text, v)
+		}
+		errs := schema.Validate(map[string]interface{}{"height": v})
+		if valid := len(errs) == 0; valid != number {
+			t.Errorf("%q: unexpected validation errors: %v", text, errs)
+		}
+	}
+	for _, n := range []json.Number{"NaN", "+1"} {
+		errs := schema.Validate(map[string]interface{}{"age": n, "height": n})
+		if len(errs) != 2 {
+			t.Errorf("%q: unexpected validation errors: %v", n, errs)
+		}
+	}
+}
+
+// a record with a malformed number fails alone, not with its batch.
+func TestImportMalformedNumber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entities []map[string]interface{}
+		err := json.Unmarshal([]byte(r.FormValue("all_attributes")), &entities)
+		if err != nil {
+			t.Error(err)
+		}
+		results := make([]string, len(entities))
+		for i := range entities {
+			results[i] = fmt.Sprintf("uuid-%d", i)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"stat": "ok", "uuid_results": results})
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	input := "email,age\n" +
+		"a@example.com,NaN\n" +
+		"b@example.com,30\n"
+	summary, err := Import(client, strings.NewReader(input), ImportOptions{
+		TypeName: "user",
+		Format:   CSV,
+		Schema:   testSchema,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Records != 2 || summary.Created != 1 || summary.Failed != 1 {
+		t.Errorf("unexpected summary: %#v", summary)
+	}
+}
+
+// failed records can be imported again.
+func TestImportFailedRoundTrip(t *testing.T) {
+	var created []map[string]interface{}
+	reject := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entities []map[string]interface{}
+		err := json.Unmarshal([]byte(r.FormValue("all_attributes")), &entities)
+		if err != nil {
+			t.Error(err)
+		}
+		var results []interface{}
+		for _, entity := range entities {
+			if reject && entity["email"] == "dup@example.com" {
+				results = append(results, map[string]interface{}{"stat": "error", "code": 360, "error": "unique_violation"})
+				continue
+			}
+			created = append(created, entity)
+			results = append(results, "uuid")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"stat": "ok", "uuid_results": results})
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	input := "mail,years,city\n" +
+		"a@example.com,30,Portland\n" +
+		"dup@example.com,31,Salem\n" +
+		"far-too-long@example.com,32,\n"
+	var failed bytes.Buffer
+	summary, err := Import(client, strings.NewReader(input), ImportOptions{
+		TypeName: "user",
+		Format:   CSV,
+		Mapping:  map[string]string{"mail": "email", "years": "age", "city": "primaryAddress.city"},
+		Schema:   testSchema,
+		Failed:   &failed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Created != 1 || summary.Failed != 2 {
+		t.Fatalf("unexpected summary: %#v", summary)
+	}
+
+	reject = false
+	created = nil
+	corrected := strings.Replace(failed.String(), "far-too-long@example.com", "short@example.com", 1)
+	if !strings.HasPrefix(failed.String(), "mail,years,city\n") {
+		t.Errorf("failed records are not in the input format:\n%s", failed.String())
+	}
+	summary, err = Import(client, strings.NewReader(corrected), ImportOptions{
+		TypeName: "user",
+		Format:   CSV,
+		Mapping:  map[string]string{"mail": "email", "years": "age", "city": "primaryAddress.city"},
+		Schema:   testSchema,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Records != 2 || summary.Created != 2 {
+		t.Fatalf("unexpected summary: %#v", summary)
+	}
+	expect := map[string]interface{}{
+		"email":          "dup@example.com",
+		"age":            float64(31),
+		"primaryAddress": map[string]interface{}{"city": "Salem"},
+	}
+	byEmail := make(map[interface{}]map[string]interface{})
+	for _, entity := range created {
+		byEmail[entity["email"]] = entity
+	}
+	if !reflect.DeepEqual(byEmail["dup@example.com"], expect) || byEmail["short@example.com"] == nil {
+		t.Errorf("unexpected entities: %#v", created)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// schema.go [created: Sun, 18 Oct 2026]
+
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the schema of an entity type, as returned by /entityType.
+type Schema struct {
+	Name     string     `json:"name"`
+	AttrDefs []*AttrDef `json:"attr_defs"`
+}
+
+// the definition of an attribute. objects and plurals have the definitions of
+// their attributes in AttrDefs.
+type AttrDef struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Length      int        `json:"length,omitempty"`
+	Constraints []string   `json:"constraints,omitempty"`
+	AttrDefs    []*AttrDef `json:"attr_defs,omitempty"`
+}
+
+// retrieve the schema of an entity type.
+func FetchSchema(client *capture.Client, typeName string) (*Schema, error) {
+	var resp struct {
+		Schema *Schema `json:"schema"`
+	}
+	err := client.ExecuteInto("/entityType", nil, capture.Params{"type_name": typeName}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Schema == nil {
+		return nil, fmt.Errorf("no schema for entity type %q", typeName)
+	}
+	return resp.Schema, nil
+}
+
+// the definition of the attribute at path, a dot separated list of attribute
+// names. nil if no such attribute is defined.
+func (s *Schema) Attr(path string) *AttrDef {
+	defs := s.AttrDefs
+	var def *AttrDef
+	for _, name := range strings.Split(path, ".") {
+		def = findAttr(defs, name)
+		if def == nil {
+			return nil
+		}
+		defs = def.AttrDefs
+	}
+	return def
+}
+
+func findAttr(defs []*AttrDef, name string) *AttrDef {
+	for _, def := range defs {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// true if the attribute has the named constraint (e.g. "required").
+func (def *AttrDef) Constrained(constraint string) bool {
+	for _, c := range def.Constraints {
+		if c == constraint {
+			return true
+		}
+	}
+	return false
+}
+
+// a value that does not conform to a schema.
+type ValidationError struct {
+	Path   string // the attribute path
+	Reason string
+}
+
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Reason)
+}
+
+// check an entity against the schema, returning an error for each
+// nonconforming attribute.
+func (s *Schema) Validate(entity map[string]interface{}) []error {
+	return validateObject(nil, "", s.AttrDefs, entity)
+}
+
+func validateObject(errs []error, prefix string, defs []*AttrDef, obj map[string]interface{}) []error {
+	for name, v := range obj {
+		def := findAttr(defs, name)
+		if def == nil {
+			errs = append(errs, &ValidationError{prefix + name, "unknown attribute"})
+			continue
+		}
+		errs = validateValue(errs, prefix+name, def, v)
+	}
+	for _, def := range defs {
+		if v, ok := obj[def.Name]; (!ok || v == nil) && def.Constrained("required") {
+			errs = append(errs, &ValidationError{prefix + def.Name, "required"})
+		}
+	}
+	return errs
+}
+
+func validateValue(errs []error, path string, def *AttrDef, v interface{}) []error {
+	if v == nil {
+		return errs
+	}
+	mismatch := func() []error {
+		return append(errs, &ValidationError{path, fmt.Sprintf("expected %s, got %T", def.Type, v)})
+	}
+	switch def.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		return validateObject(errs, path+".", def.AttrDefs, obj)
+	case "plural":
+		elems, ok := v.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for i, elem := range elems {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				errs = append(errs, &ValidationError{fmt.Sprintf("%s.%d", path, i), "expected object"})
+				continue
+			}
+			errs = validateObject(errs, fmt.Sprintf("%s.%d.", path, i), def.AttrDefs, obj)
+		}
+		return errs
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	case "integer", "id":
+		if !isInteger(v) {
+			return mismatch()
+		}
+	case "decimal":
+		switch v := v.(type) {
+		case float64, int, int64:
+		case json.Number:
+			if !isNumber(string(v)) {
+				return mismatch()
+			}
+		default:
+			return mismatch()
+		}
+	case "json":
+	default:
+		// strings, dates, passwords, uuids and others sent as text.
+		s, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		if def.Length > 0 && utf8.RuneCountInString(s) > def.Length {
+			return append(errs, &ValidationError{path, fmt.Sprintf("longer than %d characters", def.Length)})
+		}
+	}
+	return errs
+}
+
+func isInteger(v interface{}) bool {
+	switch v := v.(type) {
+	case int, int64:
+		return true
+	case float64:
+		return v == float64(int64(v))
+	case json.Number:
+		_, ok := new(big.Int).SetString(v.String(), 10)
+		return ok && isNumber(v.String())
+	}
+	return false
+}
+
+// true if text is a number in JSON syntax. strconv.ParseFloat also accepts
+// text such as "NaN", "Inf" and "0x1p-2" which cannot be sent to Capture.
+func isNumber(text string) bool {
+	var v interface{}
+	err := decodeJSON([]byte(text), &v)
+	n, ok := v.(json.Number)
+	return err == nil && ok && string(n) == text
+}
+
+// convert text, such as a CSV field, to the type of the attribute at path.
+// text that cannot be converted is returned unchanged.
+func (s *Schema) convert(path string, text string) interface{} {
+	def := s.Attr(path)
+	if def == nil {
+		return text
+	}
+	switch def.Type {
+	case "boolean":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case "integer", "id", "decimal":
+		if isNumber(text) {
+			return json.Number(text)
+		}
+	case "object", "plural", "json":
+		var v interface{}
+		if err := decodeJSON([]byte(text), &v); err == nil {
+			return v
+		}
+	}
+	return text
+}
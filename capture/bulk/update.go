@@ -0,0 +1,323 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// update.go [created: Sun, 18 Oct 2026]
+
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+	"github.com/bmatsuo1/go-janrain/capture/filter"
+
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// computes the attributes to update for an entity. a transform returns a
+// (possibly nested) object of new attribute values, or nil to leave the entity
+// unchanged. the result is compared with entity using capture.Diff, so plural
+// elements are matched by id and elements without an id are appended.
+// elements missing from a returned plural are kept unless
+// UpdateOptions.DeleteElements is set. a transform must not modify entity and
+// must be safe to call concurrently.
+type Transform func(entity map[string]interface{}) (map[string]interface{}, error)
+
+type UpdateOptions struct {
+	TypeName string
+	Filter   filter.Interface // nil selects all entities
+
+	// the attributes passed to Transform. nil retrieves all attributes. ids
+	// are always retrieved.
+	Attributes []string
+
+	// entities requested per call to /entity.find. if zero DefaultPageSize is
+	// used.
+	PageSize int
+
+	Transform Transform
+
+	// if true plural elements missing from a transform's result are removed
+	// with /entity.delete. by default they are left unchanged.
+	DeleteElements bool
+
+	// the number of entities updated concurrently. if zero one entity is
+	// updated at a time.
+	Concurrency int
+
+	// the maximum number of calls to /entity.update and /entity.delete per
+	// second. if zero calls are not limited. time spent waiting is reported to
+	// the client's capture.Metrics.
+	Rate float64
+
+	// if true no entities are updated. changes are written to Diff.
+	DryRun bool
+
+	// receives a description of each change made, or that would be made in a
+	// dry run.
+	Diff io.Writer
+
+	// the path of a file listing the ids of updated entities, one per line.
+	// entities listed in an existing log are skipped, so an interrupted update
+	// can be resumed. the log is not written in a dry run.
+	Log string
+
+	// called with the progress of the update after each entity is processed.
+	// calls are not concurrent.
+	Progress func(UpdateProgress)
+}
+
+// counts of the entities processed by Update.
+type UpdateProgress struct {
+	Matched   int // entities matching the filter
+	Updated   int // entities updated, or that would be in a dry run
+	Unchanged int // entities the transform did not change
+	Skipped   int // entities in the log of a previous update
+	Failed    int
+}
+
+// an entity that could not be updated.
+type UpdateFailure struct {
+	Id  int64
+	Err error
+}
+
+func (f *UpdateFailure) Error() string {
+	return fmt.Sprintf("entity %d: %v", f.Id, f.Err)
+}
+
+// the result of Update.
+type UpdateSummary struct {
+	UpdateProgress
+	Failures []*UpdateFailure
+}
+
+// apply opts.Transform to every entity matching opts.Filter. the returned error
+// is non-nil if entities could not be retrieved or the log could not be
+// written; failures to update individual entities are listed in the summary.
+func Update(client *capture.Client, opts UpdateOptions) (*UpdateSummary, error) {
+	done, err := readLog(opts.Log)
+	if err != nil {
+		return nil, err
+	}
+	var log *os.File
+	if opts.Log != "" && !opts.DryRun {
+		log, err = os.OpenFile(opts.Log, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		defer log.Close()
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	u := &updater{
+		client:  client,
+		opts:    opts,
+		log:     log,
+		summary: new(UpdateSummary),
+	}
+	if opts.Rate > 0 {
+		u.limit = &limiter{interval: time.Duration(float64(time.Second) / opts.Rate)}
+	}
+
+	entities := make(chan map[string]interface{})
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entity := range entities {
+				u.update(entity)
+			}
+		}()
+	}
+	err = walk(client, opts.TypeName, opts.Filter, opts.Attributes, pageSize, 0, func(entity map[string]interface{}, id int64) error {
+		if done[id] {
+			u.progress(func(p *UpdateProgress) { p.Matched++; p.Skipped++ })
+			return nil
+		}
+		entities <- entity
+		return u.error()
+	}, nil)
+	close(entities)
+	wg.Wait()
+	if err == nil {
+		err = u.error()
+	}
+	return u.summary, err
+}
+
+type updater struct {
+	client *capture.Client
+	opts   UpdateOptions
+	limit  *limiter
+
+	mut     sync.Mutex
+	log     *os.File
+	summary *UpdateSummary
+	err     error // the first error writing the log or diff
+}
+
+func (u *updater) update(entity map[string]interface{}) {
+	id, _ := entityId(entity)
+	patch, err := u.opts.Transform(entity)
+	if err != nil {
+		u.fail(id, err)
+		return
+	}
+	diff := capture.Diff(entity, patch)
+	if !u.opts.DeleteElements {
+		keepElements(diff)
+	}
+	if diff.Empty() {
+		u.progress(func(p *UpdateProgress) { p.Matched++; p.Unchanged++ })
+		return
+	}
+	if !u.opts.DryRun {
+		if diff.Attributes != nil {
+			err = u.execute("/entity.update", capture.Params{
+				"type_name": u.opts.TypeName,
+				"id":        id,
+				"value":     diff.Attributes,
+			})
+		}
+		for _, name := range diff.Removed {
+			if err != nil {
+				break
+			}
+			err = u.execute("/entity.delete", capture.Params{
+				"type_name":      u.opts.TypeName,
+				"id":             id,
+				"attribute_name": name,
+			})
+		}
+		if err != nil {
+			u.fail(id, err)
+			return
+		}
+	}
+
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	if u.opts.Diff != nil && u.err == nil {
+		_, u.err = fmt.Fprintf(u.opts.Diff, "entity %d\n%v\n", id, diff)
+	}
+	if u.log != nil && u.err == nil {
+		_, u.err = fmt.Fprintln(u.log, id)
+	}
+	u.summary.Matched++
+	u.summary.Updated++
+	u.report()
+}
+
+// drop the removal of plural elements from p.
+func keepElements(p *capture.Patch) {
+	changes := p.Changes[:0]
+	for _, c := range p.Changes {
+		if c.Op != capture.Removed {
+			changes = append(changes, c)
+		}
+	}
+	p.Changes = changes
+	p.Removed = nil
+}
+
+// make a call, waiting for the rate limit.
+func (u *updater) execute(method string, params capture.Params) error {
+	if u.limit != nil {
+		if d := u.limit.wait(); d > 0 {
+			u.client.RateLimitWait(method, d)
+		}
+	}
+	_, err := u.client.Execute(method, nil, params)
+	return err
+}
+
+func (u *updater) fail(id int64, err error) {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	u.summary.Failures = append(u.summary.Failures, &UpdateFailure{id, err})
+	u.summary.Matched++
+	u.summary.Failed++
+	u.report()
+}
+
+func (u *updater) progress(fn func(*UpdateProgress)) {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	fn(&u.summary.UpdateProgress)
+	u.report()
+}
+
+// must be called with u.mut held.
+func (u *updater) report() {
+	if u.opts.Progress != nil {
+		u.opts.Progress(u.summary.UpdateProgress)
+	}
+}
+
+func (u *updater) error() error {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	return u.err
+}
+
+// the ids in an update log. a missing log, or an empty path, has no ids.
+func readLog(path string) (map[int64]bool, error) {
+	done := make(map[int64]bool)
+	if path == "" {
+		return done, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		id, err := strconv.ParseInt(scanner.Text(), 10, 64)
+		if err != nil {
+			// a partially written last line.
+			continue
+		}
+		done[id] = true
+	}
+	return done, scanner.Err()
+}
+
+// spaces events at least interval apart.
+type limiter struct {
+	interval time.Duration
+
+	mut  sync.Mutex
+	next time.Time
+}
+
+// wait for the next event, returning the time spent waiting.
+func (l *limiter) wait() time.Duration {
+	l.mut.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	d := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mut.Unlock()
+	time.Sleep(d)
+	return d
+}
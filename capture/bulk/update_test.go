@@ -0,0 +1,170 @@
+package bulk
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	emails := map[int]string{1: "A@example.com", 2: "b@example.com", 3: "C@example.com", 4: "D@example.com"}
+	var mut sync.Mutex
+	var updated []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/entity.find":
+			var lastId int
+			fmt.Sscanf(r.FormValue("filter"), "id > %d", &lastId)
+			var results []interface{}
+			for id := lastId + 1; id <= len(emails); id++ {
+				results = append(results, map[string]interface{}{"id": id, "email": emails[id]})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"stat": "ok", "result_count": len(results), "results": results})
+		case "/entity.update":
+			mut.Lock()
+			updated = append(updated, r.FormValue("id")+" "+r.FormValue("value"))
+			mut.Unlock()
+			if r.FormValue("id") == "4" {
+				fmt.Fprint(w, `{"stat":"error","code":510,"error":"rate_limit_exceeded"}`)
+				return
+			}
+			fmt.Fprint(w, `{"stat":"ok"}`)
+		}
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	logpath := filepath.Join(t.TempDir(), "updated.log")
+	err := ioutil.WriteFile(logpath, []byte("1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diff bytes.Buffer
+	var last UpdateProgress
+	opts := UpdateOptions{
+		TypeName: "user",
+		Transform: func(entity map[string]interface{}) (map[string]interface{}, error) {
+			email, _ := entity["email"].(string)
+			return map[string]interface{}{"email": strings.ToLower(email)}, nil
+		},
+		Concurrency: 2,
+		Rate:        1000,
+		DryRun:      true,
+		Diff:        &diff,
+		Log:         logpath,
+		Progress:    func(p UpdateProgress) { last = p },
+	}
+	summary, err := Update(client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("dry run updated entities: %v", updated)
+	}
+	if summary.Matched != 4 || summary.Skipped != 1 || summary.Unchanged != 1 || summary.Updated != 2 || last != summary.UpdateProgress {
+		t.Errorf("unexpected dry run summary: %#v", summary)
+	}
+	if !strings.Contains(diff.String(), "entity 3\n~ email: \"C@example.com\" -> \"c@example.com\"\n") {
+		t.Errorf("unexpected diff:\n%s", diff.String())
+	}
+
+	opts.DryRun = false
+	opts.Diff = nil
+	summary, err = Update(client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Updated != 1 || summary.Failed != 1 || len(summary.Failures) != 1 || summary.Failures[0].Id != 4 {
+		t.Errorf("unexpected summary: %#v", summary)
+	}
+	if len(updated) != 2 {
+		t.Errorf("unexpected updates: %v", updated)
+	}
+	p, _ := ioutil.ReadFile(logpath)
+	if string(p) != "1\n3\n" {
+		t.Errorf("unexpected log: %q", p)
+	}
+}
+
+// plural elements missing from the transform's result are kept unless
+// DeleteElements is set.
+func TestUpdatePlural(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/entity.find":
+			var lastId int
+			fmt.Sscanf(r.FormValue("filter"), "id > %d", &lastId)
+			if lastId > 0 {
+				fmt.Fprint(w, `{"stat":"ok","result_count":0,"results":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"stat":"ok","result_count":1,"results":[
+				{"id":1,"profiles":[{"id":10,"domain":"a.com"},{"id":11,"domain":"b.com"}]}
+			]}`)
+		case "/entity.update":
+			calls = append(calls, "update "+r.FormValue("value"))
+			fmt.Fprint(w, `{"stat":"ok"}`)
+		case "/entity.delete":
+			calls = append(calls, "delete "+r.FormValue("attribute_name"))
+			fmt.Fprint(w, `{"stat":"ok"}`)
+		}
+	}))
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	for _, test := range []struct {
+		delete bool
+		calls  []string
+		diff   string
+	}{
+		{
+			false,
+			[]string{`update {"profiles":[{"domain":"c.com"}]}`},
+			"entity 1\n+ profiles#new: {\"domain\":\"c.com\"}\n",
+		},
+		{
+			true,
+			[]string{`update {"profiles":[{"domain":"c.com"}]}`, "delete profiles#11"},
+			"entity 1\n- profiles#11: {\"domain\":\"b.com\",\"id\":11}\n+ profiles#new: {\"domain\":\"c.com\"}\n",
+		},
+	} {
+		calls = nil
+		var diff bytes.Buffer
+		summary, err := Update(client, UpdateOptions{
+			TypeName: "user",
+			Transform: func(entity map[string]interface{}) (map[string]interface{}, error) {
+				profiles := entity["profiles"].([]interface{})
+				return map[string]interface{}{
+					"profiles": []interface{}{profiles[0], map[string]interface{}{"domain": "c.com"}},
+				}, nil
+			},
+			DeleteElements: test.delete,
+			Diff:           &diff,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Updated != 1 {
+			t.Errorf("delete=%v: unexpected summary: %#v", test.delete, summary)
+		}
+		if !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("delete=%v: unexpected calls: %q", test.delete, calls)
+		}
+		if diff.String() != test.diff {
+			t.Errorf("delete=%v: unexpected diff:\n%s", test.delete, diff.String())
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// cache.go [created: Sun, 18 Oct 2026]
+
+/*
+Package cache caches the responses of read-only Capture API calls.
+
+A Client wraps a capture.Client. Calls to read-only methods are answered from
+a Store when a response for the same method, parameters and authorization has
+not yet expired. Requests with different headers are cached separately.
+
+	client := capture.NewClient("https://myapp.janraincapture.com", &creds)
+	cached := cache.New(client, nil)
+	resp, err := cached.Execute("/entity", nil, capture.Params{
+		"type_name": "user",
+		"uuid":      uuid,
+	})
+
+Entities read through a Client are forgotten when the same Client is used to
+call /entity.update, /entity.replace or /entity.delete for them. Changes made
+by other clients, and counts affected by changes, are only reflected once
+cached responses expire.
+
+Requests authorized by an Authorization other than those defined by the
+capture package are only cached if the Authorization implements Identifier.
+*/
+package cache
+
+import (
+	"github.com/bitly/go-simplejson"
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the methods cached by a new Client and how long their responses are kept.
+var DefaultTTL = map[string]time.Duration{
+	"/entity":         time.Minute,
+	"/entity.count":   time.Minute,
+	"/entityType":     10 * time.Minute,
+	"/settings/items": 10 * time.Minute,
+}
+
+// the bounds of the LRU used by a Client constructed without a Store.
+var (
+	DefaultMaxEntries       = 10000
+	DefaultMaxBytes   int64 = 64 << 20
+)
+
+// methods that invalidate the cached responses of the entity they change.
+var invalidating = map[string]bool{
+	"/entity.update":  true,
+	"/entity.replace": true,
+	"/entity.delete":  true,
+}
+
+// the number of entities tracked for invalidation above which a Client
+// forgets entities without cached responses.
+var indexLimit = 10000
+
+// an Authorization that identifies the user or client it authorizes.
+// Authorizations with the same identity share cached responses.
+type Identifier interface {
+	Identity() string
+}
+
+// a capture.Client that caches responses to read-only calls.
+type Client struct {
+	Client *capture.Client
+	store  Store
+	ttl    map[string]time.Duration
+
+	mut   sync.Mutex
+	index map[string]map[string]bool // entity key -> cache keys
+
+	// reads in progress may not cache responses for entities invalidated
+	// after they began. gen is incremented by each invalidation and
+	// invalidated holds the gen of each entity's last invalidation while
+	// reads are in progress.
+	reads       int
+	gen         uint64
+	invalidated map[string]uint64
+}
+
+// construct a Client caching the methods in DefaultTTL. if store is nil
+// responses are kept in an LRU bounded by DefaultMaxEntries and DefaultMaxBytes.
+func New(client *capture.Client, store Store) *Client {
+	if store == nil {
+		store = NewLRU(DefaultMaxEntries, DefaultMaxBytes)
+	}
+	ttl := make(map[string]time.Duration, len(DefaultTTL))
+	for method, d := range DefaultTTL {
+		ttl[method] = d
+	}
+	return &Client{
+		Client:      client,
+		store:       store,
+		ttl:         ttl,
+		index:       make(map[string]map[string]bool),
+		invalidated: make(map[string]uint64),
+	}
+}
+
+// set how long responses to method are cached. a non-positive ttl disables
+// caching of method.
+func (c *Client) SetTTL(method string, ttl time.Duration) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if ttl <= 0 {
+		delete(c.ttl, methodKey(method))
+		return
+	}
+	c.ttl[methodKey(method)] = ttl
+}
+
+// see capture.Client.Execute.
+func (c *Client) Execute(method string, header http.Header, params capture.Params) (*simplejson.Json, error) {
+	return c.ExecuteAuth(c.Client.Auth(), method, header, params)
+}
+
+// see capture.Client.ExecuteAuth.
+func (c *Client) ExecuteAuth(auth capture.Authorization, method string, header http.Header, params capture.Params) (*simplejson.Json, error) {
+	p, err := c.execute(auth, method, header, params)
+	if err != nil {
+		return nil, err
+	}
+	js := new(simplejson.Json)
+	err = json.Unmarshal(p, js)
+	if err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+// see capture.Client.ExecuteInto.
+func (c *Client) ExecuteInto(method string, header http.Header, params capture.Params, out interface{}) error {
+	return c.ExecuteAuthInto(c.Client.Auth(), method, header, params, out)
+}
+
+// see capture.Client.ExecuteAuthInto.
+func (c *Client) ExecuteAuthInto(auth capture.Authorization, method string, header http.Header, params capture.Params, out interface{}) error {
+	p, err := c.execute(auth, method, header, params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(p, out)
+}
+
+// remove all cached responses for an entity identified by uuid.
+func (c *Client) Invalidate(uuid string) {
+	c.invalidate([]string{"uuid:" + uuid})
+}
+
+// the raw response of a call, which may be cached.
+func (c *Client) execute(auth capture.Authorization, method string, header http.Header, params capture.Params) ([]byte, error) {
+	method = methodKey(method)
+	ident, identified := identity(auth)
+
+	c.mut.Lock()
+	ttl, cacheable := c.ttl[method]
+	c.mut.Unlock()
+	if !cacheable || !identified {
+		var raw json.RawMessage
+		err := c.Client.ExecuteAuthInto(auth, method, header, params, &raw)
+		if invalidating[method] {
+			// invalidate even on failure, the change may have been made.
+			c.invalidate(entityKeys(ident, params, nil))
+		}
+		return raw, err
+	}
+
+	key, err := c.key(ident, method, header, params)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := c.store.Get(key); ok {
+		return p, nil
+	}
+	start := c.begin()
+	var raw json.RawMessage
+	err = c.Client.ExecuteAuthInto(auth, method, header, params, &raw)
+	if err != nil {
+		c.finish(start, "", nil, 0, nil)
+		return nil, err
+	}
+	var entities []string
+	if method == "/entity" {
+		var result struct {
+			Result map[string]interface{} `json:"result"`
+		}
+		json.Unmarshal(raw, &result)
+		entities = entityKeys(ident, params, result.Result)
+	}
+	c.finish(start, key, raw, ttl, entities)
+	return raw, nil
+}
+
+// start a read, returning the generation it began at.
+func (c *Client) begin() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.reads++
+	return c.gen
+}
+
+// end a read that began at generation start, caching its response under key
+// unless one of its entities was invalidated since. a nil value caches
+// nothing.
+func (c *Client) finish(start uint64, key string, value []byte, ttl time.Duration, entities []string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.reads--
+	stale := false
+	for _, entity := range entities {
+		if c.invalidated[entity] > start {
+			stale = true
+		}
+	}
+	if value != nil && !stale {
+		c.store.Set(key, value, ttl)
+		c.track(entities, key)
+	}
+	if c.reads == 0 && len(c.invalidated) > 0 {
+		c.invalidated = make(map[string]uint64)
+	}
+}
+
+// the cache key for a call. headers and parameters sent with every call by the
+// wrapped client are included.
+func (c *Client) key(ident, method string, header http.Header, params capture.Params) (string, error) {
+	merged := make(http.Header, len(header))
+	for k, v := range c.Client.Header() {
+		merged[k] = v
+	}
+	for k, v := range header {
+		merged[k] = v
+	}
+	var hbuf strings.Builder
+	// http.Header.Write sorts by key.
+	merged.Write(&hbuf)
+
+	mergedparams := make(capture.Params, len(params))
+	for k, v := range c.Client.Params() {
+		mergedparams[k] = v
+	}
+	for k, v := range params {
+		mergedparams[k] = v
+	}
+	values, err := mergedparams.Values()
+	if err != nil {
+		return "", err
+	}
+	// url.Values.Encode sorts by key.
+	return fmt.Sprintf("%s\n%s\n%s\n%s", ident, method, values.Encode(), hbuf.String()), nil
+}
+
+// associate a cache key with entities. must be called with c.mut held.
+func (c *Client) track(entities []string, key string) {
+	if len(entities) == 0 {
+		return
+	}
+	if len(c.index) > indexLimit {
+		c.prune()
+	}
+	for _, entity := range entities {
+		keys := c.index[entity]
+		if keys == nil {
+			keys = make(map[string]bool)
+			c.index[entity] = keys
+		}
+		keys[key] = true
+	}
+}
+
+// forget entities without cached responses. must be called with c.mut held.
+func (c *Client) prune() {
+	for entity, keys := range c.index {
+		for key := range keys {
+			if !c.store.Contains(key) {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(c.index, entity)
+		}
+	}
+}
+
+func (c *Client) invalidate(entities []string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.gen++
+	for _, entity := range entities {
+		if c.reads > 0 {
+			c.invalidated[entity] = c.gen
+		}
+		for key := range c.index[entity] {
+			c.store.Delete(key)
+		}
+		delete(c.index, entity)
+	}
+}
+
+// the keys identifying the entity targeted by a call. an entity may be
+// identified by its uuid, by its type and id, or by the access token of its
+// user. result is the entity returned by the call, if any.
+func entityKeys(ident string, params capture.Params, result map[string]interface{}) []string {
+	var keys []string
+	typeName, _ := params["type_name"].(string)
+	if uuid, ok := params["uuid"]; ok {
+		keys = append(keys, fmt.Sprintf("uuid:%v", uuid))
+	} else if id, ok := params["id"]; ok {
+		keys = append(keys, fmt.Sprintf("id:%s:%v", typeName, id))
+	} else if strings.HasPrefix(ident, "token:") {
+		keys = append(keys, ident)
+	}
+	if uuid, ok := result["uuid"]; ok {
+		keys = append(keys, fmt.Sprintf("uuid:%v", uuid))
+	}
+	if id, ok := result["id"]; ok {
+		keys = append(keys, fmt.Sprintf("id:%s:%v", typeName, id))
+	}
+	return keys
+}
+
+// the identity of an Authorization. ok is false if the Authorization's
+// identity cannot be determined.
+func identity(auth capture.Authorization) (ident string, ok bool) {
+	switch auth := auth.(type) {
+	case nil:
+		return "", true
+	case Identifier:
+		return "id:" + auth.Identity(), true
+	case *capture.ClientCredentials:
+		return "client:" + auth.Id, true
+	case *capture.ClientCredentialsSimple:
+		return "client:" + auth.Id, true
+	case capture.AccessToken:
+		return "token:" + digest(string(auth)), true
+	case *capture.TokenSource:
+		// the token changes on refresh but the user does not.
+		return fmt.Sprintf("token:%p", auth), true
+	}
+	return "", false
+}
+
+// keeps access tokens out of cache keys.
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func methodKey(method string) string {
+	if strings.HasPrefix(method, "/") {
+		return method
+	}
+	return "/" + method
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	calls := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/entity":
+			fmt.Fprintf(w, `{"stat":"ok","result":{"uuid":"abc","id":%d}}`, calls[r.URL.Path])
+		default:
+			fmt.Fprint(w, `{"stat":"ok"}`)
+		}
+	}))
+	defer server.Close()
+	client := New(capture.NewClient(server.URL, nil), NewLRU(10, 0))
+
+	get := func(params capture.Params) int {
+		resp, err := client.Execute("/entity", nil, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Get("result").Get("id").MustInt()
+	}
+	byUuid := capture.Params{"type_name": "user", "uuid": "abc"}
+	if get(byUuid) != 1 || get(byUuid) != 1 {
+		t.Errorf("response was not cached")
+	}
+	if get(capture.Params{"type_name": "user", "uuid": "def"}) != 2 {
+		t.Errorf("distinct params shared a response")
+	}
+
+	_, err := client.Execute("/entity.update", nil, capture.Params{
+		"type_name":  "user",
+		"uuid":       "abc",
+		"attributes": map[string]interface{}{"givenName": "Bob"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get(byUuid) != 3 {
+		t.Errorf("response was not invalidated by update")
+	}
+	if calls["/entity.update"] != 1 {
+		t.Errorf("unexpected number of updates: %d", calls["/entity.update"])
+	}
+}
+
+// a Client without a Store caches in an LRU, and calls differing only in
+// headers are cached separately.
+func TestClientHeaders(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"stat":"ok","result":{"uuid":"abc","id":%d,"lang":%q}}`, n, r.Header.Get("Accept-Language"))
+	}))
+	defer server.Close()
+	client := New(capture.NewClient(server.URL, nil), nil)
+
+	params := capture.Params{"type_name": "user", "uuid": "abc"}
+	get := func(lang string) (int, string) {
+		resp, err := client.Execute("/entity", http.Header{"Accept-Language": {lang}}, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := resp.Get("result")
+		return result.Get("id").MustInt(), result.Get("lang").MustString()
+	}
+	if id, _ := get("en"); id != 1 {
+		t.Errorf("unexpected response: %d", id)
+	}
+	if id, _ := get("en"); id != 1 {
+		t.Errorf("response was not cached")
+	}
+	if id, lang := get("fr"); id != 2 || lang != "fr" {
+		t.Errorf("distinct headers shared a response: %d %q", id, lang)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	lru := NewLRU(2, 0)
+	now := time.Unix(0, 0)
+	lru.now = func() time.Time { return now }
+	lru.Set("a", []byte("1"), time.Minute)
+	lru.Set("b", []byte("2"), time.Minute)
+	lru.Get("a")
+	lru.Set("c", []byte("3"), time.Minute)
+	if _, ok := lru.Get("b"); ok {
+		t.Errorf("least recently used entry was not evicted")
+	}
+	if p, ok := lru.Get("a"); !ok || string(p) != "1" {
+		t.Errorf("unexpected entry: %q %v", p, ok)
+	}
+	now = now.Add(time.Hour)
+	if _, ok := lru.Get("a"); ok {
+		t.Errorf("expired entry was returned")
+	}
+
+	now = time.Unix(0, 0)
+	lru.Set("a", []byte("1"), time.Minute)
+	lru.Set("b", []byte("2"), time.Minute)
+	if !lru.Contains("a") || lru.Contains("c") {
+		t.Errorf("unexpected Contains results")
+	}
+	lru.Set("c", []byte("3"), time.Minute)
+	if lru.Contains("a") {
+		t.Errorf("Contains changed the order of entries")
+	}
+	now = now.Add(time.Hour)
+	if lru.Contains("b") {
+		t.Errorf("expired entry is contained")
+	}
+}
+
+// a response read while its entity is invalidated is not cached.
+func TestClientInvalidateInFlight(t *testing.T) {
+	var calls int32
+	block := make(chan bool)
+	started := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			started <- true
+			<-block
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"stat":"ok","result":{"uuid":"abc","id":%d}}`, n)
+	}))
+	defer server.Close()
+	client := New(capture.NewClient(server.URL, nil), NewLRU(10, 0))
+	byUuid := capture.Params{"type_name": "user", "uuid": "abc"}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Execute("/entity", nil, byUuid)
+		done <- err
+	}()
+	<-started
+	client.Invalidate("abc")
+	close(block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Execute("/entity", nil, byUuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := resp.Get("result").Get("id").MustInt(); id != 2 {
+		t.Errorf("stale response was cached: id %d", id)
+	}
+	resp, _ = client.Execute("/entity", nil, byUuid)
+	if id := resp.Get("result").Get("id").MustInt(); id != 2 {
+		t.Errorf("response was not cached: id %d", id)
+	}
+}
+
+// counts calls to Get.
+type countingStore struct {
+	*LRU
+	gets int
+}
+
+func (s *countingStore) Get(key string) ([]byte, bool) {
+	s.gets++
+	return s.LRU.Get(key)
+}
+
+func TestClientPrune(t *testing.T) {
+	limit := indexLimit
+	indexLimit = 1
+	defer func() { indexLimit = limit }()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"stat":"ok","result":{"uuid":%q}}`, r.FormValue("uuid"))
+	}))
+	defer server.Close()
+	store := &countingStore{LRU: NewLRU(2, 0)}
+	client := New(capture.NewClient(server.URL, nil), store)
+
+	for _, uuid := range []string{"a", "b", "c", "d"} {
+		_, err := client.Execute("/entity", nil, capture.Params{"type_name": "user", "uuid": uuid})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.gets != 4 {
+		t.Errorf("pruning used the store: %d gets", store.gets)
+	}
+	if len(client.index) > 2 {
+		t.Errorf("evicted entities were not pruned: %v", client.index)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// lru.go [created: Sun, 18 Oct 2026]
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// storage for cached responses. a Store must be safe for use by multiple
+// goroutines. a Store may discard entries at any time.
+type Store interface {
+	// retrieve an unexpired value.
+	Get(key string) ([]byte, bool)
+	// true if an unexpired value is stored. unlike Get, Contains does not
+	// count as a use of the value.
+	Contains(key string) bool
+	// store a value for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+// an in-memory Store that discards the least recently used entries when it
+// holds more than a maximum number of entries or bytes.
+type LRU struct {
+	maxEntries int
+	maxBytes   int64
+
+	mut     sync.Mutex
+	nbytes  int64
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// construct an LRU holding at most maxEntries entries and maxBytes bytes of
+// values. a non-positive bound is not enforced.
+func NewLRU(maxEntries int, maxBytes int64) *LRU {
+	return &LRU{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (lru *LRU) Get(key string) ([]byte, bool) {
+	lru.mut.Lock()
+	defer lru.mut.Unlock()
+	elem, ok := lru.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !lru.now().Before(entry.expires) {
+		lru.remove(elem)
+		return nil, false
+	}
+	lru.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (lru *LRU) Contains(key string) bool {
+	lru.mut.Lock()
+	defer lru.mut.Unlock()
+	elem, ok := lru.entries[key]
+	return ok && lru.now().Before(elem.Value.(*lruEntry).expires)
+}
+
+func (lru *LRU) Set(key string, value []byte, ttl time.Duration) {
+	lru.mut.Lock()
+	defer lru.mut.Unlock()
+	if elem, ok := lru.entries[key]; ok {
+		lru.remove(elem)
+	}
+	if lru.maxBytes > 0 && int64(len(value)) > lru.maxBytes {
+		return
+	}
+	entry := &lruEntry{key, value, lru.now().Add(ttl)}
+	lru.entries[key] = lru.order.PushFront(entry)
+	lru.nbytes += int64(len(value))
+	for lru.overfull() {
+		lru.remove(lru.order.Back())
+	}
+}
+
+func (lru *LRU) Delete(key string) {
+	lru.mut.Lock()
+	defer lru.mut.Unlock()
+	if elem, ok := lru.entries[key]; ok {
+		lru.remove(elem)
+	}
+}
+
+// the number of entries in the cache, including expired entries that have
+// not been discarded.
+func (lru *LRU) Len() int {
+	lru.mut.Lock()
+	defer lru.mut.Unlock()
+	return lru.order.Len()
+}
+
+func (lru *LRU) overfull() bool {
+	if lru.maxEntries > 0 && lru.order.Len() > lru.maxEntries {
+		return true
+	}
+	return lru.maxBytes > 0 && lru.nbytes > lru.maxBytes
+}
+
+// must be called with lru.mut held.
+func (lru *LRU) remove(elem *list.Element) {
+	entry := lru.order.Remove(elem).(*lruEntry)
+	delete(lru.entries, entry.key)
+	lru.nbytes -= int64(len(entry.value))
+}
@@ -31,6 +31,29 @@ to that user.
 
 	// ...
 
+Verifying signatures
+
+Servers that sit in front of Capture, or stand in for it, can check requests
+signed with client credentials using VerifySignature, or a Verifier with its own
+Clock.
+
+	err := capture.VerifySignature(req, secrets.Lookup, 5*time.Minute)
+
+Refreshing access tokens
+
+Access tokens expire. A TokenSource holds an access/refresh token pair and
+refreshes the access token through /oauth/token shortly before it expires, or
+when Capture rejects it as expired.
+
+	creds := capture.ClientCredentials{"myclientid", "myclientsecret"}
+	client := capture.NewClient("https://myapp.janraincapture.com", &creds)
+	src := capture.NewTokenSource(client, &creds, capture.Token{
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+	})
+	src.OnRefresh = func(token capture.Token) { session.Save(token) }
+	resp, _ := client.ExecuteAuth(src, "/entity", nil, nil)
+
 Authorization override
 
 The two authorization methods can be mixed within the same client using the
@@ -53,6 +76,34 @@ ExecuteAuth method.
 
 	//..
 
+Typed responses
+
+Responses can be decoded directly into Go values with ExecuteInto. Unlike the
+Must* methods of simplejson, a type mismatch is reported as an error.
+
+	var user struct {
+		Uuid      string `json:"uuid"`
+		GivenName string `json:"givenName"`
+	}
+	err := client.Entity("user", uuid, nil, &user)
+
+Logging
+
+Each request made by a Client can be reported to a log hook as a CallEvent.
+Secrets and access tokens are redacted. SlogHook logs events with log/slog,
+including response bodies when the logger is enabled for debug messages.
+
+	client.SetLogHook(capture.SlogHook(slog.Default()))
+
+Tracing
+
+A Client given a Tracer starts a span for each call. Calls made through
+WithContext are children of the span in the context, and a Tracer that is also
+a Propagator adds trace context headers to requests.
+
+	client.SetTracer(tracer)
+	resp, err := client.WithContext(ctx).Execute("/entity", nil, params)
+
 Filter strings
 
 Type safe filter strings can be generated using the package
@@ -66,12 +117,10 @@ package capture
 
 import (
 	"github.com/bitly/go-simplejson"
+	"github.com/bmatsuo1/go-janrain"
 
-	"compress/gzip"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"time"
 )
@@ -92,9 +141,30 @@ func Datestamp(t time.Time) string {
 	return t.Format(DateFormat)
 }
 
-// create a time.Time from a timestamp returned by Capture.
+// the layouts of timestamps returned by Capture, tried in order by Time.
+// fractional seconds are optional in each. timestamps without a zone are UTC.
+var TimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// create a time.Time from a timestamp returned by Capture. TimeFormat and
+// TimeLayouts are accepted.
 func Time(timestamp string) (time.Time, error) {
-	return time.Parse(TimeFormat, timestamp)
+	t, err := time.Parse(TimeFormat, timestamp)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range TimeLayouts {
+		if t, _err := time.Parse(layout, timestamp); _err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
 }
 
 // create a timestamp for passing to Capture.
@@ -110,21 +180,29 @@ type RemoteError struct {
 	Description string
 	Response    *simplejson.Json
 	*HttpResponseData
+	*HttpRequestData
 }
 
-// construct an error from a Capture response.
+// construct an error from a Capture response. the response body has already
+// been consumed so the body of the error's HttpResponseData is re-encoded from
+// js. errors returned by a Client retain the body as it was received.
 func NewRemoteError(resp *http.Response, js *simplejson.Json) RemoteError {
+	return newRemoteError(nil, &HttpResponseData{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       []byte(jsonStringer(js).String()),
+	}, js)
+}
+
+func newRemoteError(req *HttpRequestData, r *HttpResponseData, js *simplejson.Json) RemoteError {
 	return RemoteError{
-		RequestId:   js.Get("request_id").MustString(),
-		Code:        js.Get("code").MustInt(),
-		Kind:        js.Get("error").MustString(),
-		Description: js.Get("error_description").MustString(),
-		Response:    js,
-		HttpResponseData: &HttpResponseData{
-			StatusCode: resp.StatusCode,
-			Header:     resp.Header,
-			Body:       []byte(jsonStringer(js).String()), // totally gnarly. this is not a good thing
-		},
+		RequestId:        js.Get("request_id").MustString(),
+		Code:             js.Get("code").MustInt(),
+		Kind:             js.Get("error").MustString(),
+		Description:      js.Get("error_description").MustString(),
+		Response:         js,
+		HttpResponseData: r,
+		HttpRequestData:  req,
 	}
 }
 
@@ -132,110 +210,38 @@ func (err RemoteError) HttpResponse() *HttpResponseData {
 	return err.HttpResponseData
 }
 
-func (err RemoteError) Error() string {
-	return fmt.Sprintf("[%s] %s", err.Kind, err.Description)
-}
-
-// an error in http communication.
-type HttpTransportError struct {
-	Err error
-}
-
-func (err HttpTransportError) Error() string {
-	return err.Err.Error()
+func (err RemoteError) HttpRequest() *HttpRequestData {
+	return err.HttpRequestData
 }
 
-// an error decoding a JSON response from the API.
-type JsonDecoderError struct {
-	r   *HttpResponseData
-	Err error
-}
-
-func (err JsonDecoderError) Error() string {
-	return err.Err.Error()
-}
-
-func (err JsonDecoderError) HttpResponse() *HttpResponseData {
-	return err.r
-}
-
-type EncodingError struct {
-	Encoding string
-	Err      error
-	*HttpResponseData
-}
-
-func (err EncodingError) Error() string {
-	return fmt.Sprintf("error decoding %s data: %v", err.Encoding, err.Err)
-}
-
-var UnknownEncoding = fmt.Errorf("unknown encoding")
-
-// an unexpected content type returned by the API.
-type ContentTypeError struct {
-	*HttpResponseData
-}
-
-func (err *ContentTypeError) Error() string {
-	return fmt.Sprintf("unexpected content-type %q", err.Header.Get("Content-Type"))
-}
-
-func (err *ContentTypeError) HttpResponse() *HttpResponseData {
-	return err.HttpResponseData
-}
-
-type HttpResponse interface {
-	HttpResponse() *HttpResponseData
+func (err RemoteError) Error() string {
+	return fmt.Sprintf("[%s] %s", err.Kind, err.Description)
 }
 
-type HttpResponseData struct {
-	StatusCode int
-	Header     http.Header
-	Body       []byte
-}
+// transport types shared with other Janrain API packages.
+type (
+	Authorization      = janrain.Authorization
+	Params             = janrain.Params
+	HttpTransportError = janrain.HttpTransportError
+	JsonDecoderError   = janrain.JsonDecoderError
+	EncodingError      = janrain.EncodingError
+	ContentTypeError   = janrain.ContentTypeError
+	HttpResponse       = janrain.HttpResponse
+	HttpResponseData   = janrain.HttpResponseData
+	HttpRequest        = janrain.HttpRequest
+	HttpRequestData    = janrain.HttpRequestData
+)
 
-func (r *HttpResponseData) String() string {
-	return fmt.Sprintf("[%d] %q", r.StatusCode, r.Body)
-}
+var UnknownEncoding = janrain.UnknownEncoding
 
+// see janrain.ReadResponse.
 func ReadResponse(resp *http.Response) (*HttpResponseData, error) {
-	defer resp.Body.Close()
-
-	var rd io.Reader
-	var err error
-	encoding := resp.Header.Get("Content-Encoding")
-	if encoding == "gzip" {
-		rd, err = gzip.NewReader(resp.Body)
-		if err != nil {
-			p, _ := ioutil.ReadAll(rd)
-			return nil, EncodingError{encoding, err, &HttpResponseData{
-				StatusCode: resp.StatusCode,
-				Header:     resp.Header,
-				Body:       p,
-			}}
-		}
-	} else if encoding != "" {
-		p, _ := ioutil.ReadAll(rd)
-		return nil, EncodingError{encoding, UnknownEncoding, &HttpResponseData{
-			StatusCode: resp.StatusCode,
-			Header:     resp.Header,
-			Body:       p,
-		}}
-	} else {
-		rd = resp.Body
-	}
-
-	p, err := ioutil.ReadAll(rd)
-	if err != nil {
-		return nil, err
-	}
+	return janrain.ReadResponse(resp)
+}
 
-	r := &HttpResponseData{
-		StatusCode: resp.StatusCode,
-		Header:     resp.Header,
-		Body:       p,
-	}
-	return r, err
+// see janrain.DecodeResponse.
+func DecodeResponse(resp *http.Response) (*HttpResponseData, *simplejson.Json, error) {
+	return janrain.DecodeResponse(resp)
 }
 
 type jstringer struct {
@@ -1,6 +1,7 @@
 package capture
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -28,3 +29,27 @@ func TestErrors(t *testing.T) {
 		t.Errorf("unexpected remote error message: %q", remerr.Error())
 	}
 }
+
+func TestErrorCodes(t *testing.T) {
+	var err error = RemoteError{Code: 310, Kind: "record_not_found"}
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("%v is not ErrRecordNotFound", err)
+	}
+	if errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("%v is ErrUniqueViolation", err)
+	}
+	err = fmt.Errorf("lookup failed: %w", RemoteError{Code: 414})
+	if !errors.Is(err, ErrAccessTokenExpired) {
+		t.Errorf("%v is not ErrAccessTokenExpired", err)
+	}
+
+	cause := fmt.Errorf("connection reset")
+	err = HttpTransportError{Err: cause}
+	if !errors.Is(err, cause) {
+		t.Errorf("%v does not wrap its cause", err)
+	}
+	err = EncodingError{Encoding: "zstd", Err: UnknownEncoding}
+	if !errors.Is(err, UnknownEncoding) {
+		t.Errorf("%v does not wrap UnknownEncoding", err)
+	}
+}
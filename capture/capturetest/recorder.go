@@ -0,0 +1,289 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// recorder.go [created: Sun, 18 Oct 2026]
+
+/*
+Package capturetest records Capture API interactions to cassette files and
+replays them, so tests that exercise a real app can run offline.
+
+	rec, err := capturetest.New("testdata/entity.json", capturetest.Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Save()
+	client := capture.NewClient(baseurl, &creds)
+	rec.Wrap(client)
+
+Secrets, access tokens and signatures are scrubbed before interactions are
+written. Requests are matched to recorded interactions by http method, path
+and form parameters. The values of sensitive parameters and all headers,
+including the Date and Authorization headers set by ClientCredentials, are
+ignored when matching. An interaction is replayed once for each time it was
+recorded.
+*/
+package capturetest
+
+import (
+	"github.com/bmatsuo1/go-janrain"
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// determines whether a Recorder sends requests or replays them.
+type Mode int
+
+const (
+	// replay the cassette if its file exists, otherwise record it.
+	Auto Mode = iota
+	// send requests and record their responses.
+	Record
+	// answer requests from the cassette without sending them.
+	Replay
+)
+
+// the contents of a cassette file.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// a request with sensitive parameters and headers redacted.
+type RecordedRequest struct {
+	Verb   string      `json:"verb"`
+	Path   string      `json:"path"`
+	Form   url.Values  `json:"form,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// a response whose body has had any Content-Encoding removed.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// no interaction in the cassette matches a request.
+type NoMatchError struct {
+	Verb, Path string
+	Form       url.Values
+}
+
+func (err *NoMatchError) Error() string {
+	return fmt.Sprintf("no recorded interaction for %s %s %s", err.Verb, err.Path, err.Form.Encode())
+}
+
+// an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	// the transport requests are sent with while recording. if nil
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	path string
+	mode Mode
+
+	mut      sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// construct a recorder for the cassette file at path. in Replay mode, and in
+// Auto mode when the file exists, the cassette is read from path.
+func New(path string, mode Mode) (*Recorder, error) {
+	rec := &Recorder{path: path, mode: mode, cassette: new(Cassette)}
+	if mode == Auto {
+		rec.mode = Record
+		if _, err := os.Stat(path); err == nil {
+			rec.mode = Replay
+		}
+	}
+	if rec.mode == Replay {
+		p, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(p, rec.cassette)
+		if err != nil {
+			return nil, err
+		}
+		rec.used = make([]bool, len(rec.cassette.Interactions))
+	}
+	return rec, nil
+}
+
+// Record or Replay.
+func (rec *Recorder) Mode() Mode {
+	return rec.mode
+}
+
+// route the requests of client through the recorder. the client's transport
+// is used to send requests while recording.
+func (rec *Recorder) Wrap(client *capture.Client) {
+	c := new(http.Client)
+	if hc := client.HttpClient(); hc != nil {
+		*c = *hc
+	}
+	if rec.Transport == nil {
+		rec.Transport = c.Transport
+	}
+	c.Transport = rec
+	client.SetHttpClient(c)
+}
+
+// write recorded interactions to the cassette file. Save does nothing when
+// replaying.
+func (rec *Recorder) Save() error {
+	if rec.mode != Record {
+		return nil
+	}
+	rec.mut.Lock()
+	p, err := json.MarshalIndent(rec.cassette, "", "\t")
+	rec.mut.Unlock()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(rec.path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(rec.path, append(p, '\n'), 0644)
+}
+
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if rec.mode == Replay {
+		return rec.replay(req, recorded)
+	}
+	return rec.record(req, recorded)
+}
+
+func (rec *Recorder) replay(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	rec.mut.Lock()
+	defer rec.mut.Unlock()
+	for i, interaction := range rec.cassette.Interactions {
+		if rec.used[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		rec.used[i] = true
+		return interaction.Response.response(req), nil
+	}
+	return nil, &NoMatchError{recorded.Verb, recorded.Path, recorded.Form}
+}
+
+func (rec *Recorder) record(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	transport := rec.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := janrain.ResponseBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	p, err := ioutil.ReadAll(body)
+	body.Close()
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := janrain.RedactHeader(resp.Header)
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	header.Del("Date")
+	response := RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       janrain.RedactJSON(string(p)),
+	}
+
+	rec.mut.Lock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, &Interaction{
+		Request:  *recorded,
+		Response: response,
+	})
+	rec.mut.Unlock()
+
+	// the caller receives the response as it was sent, less its encoding.
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(p))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(p))
+	return resp, nil
+}
+
+// a scrubbed copy of req. a form encoded request body is restored after it is
+// read.
+func recordRequest(req *http.Request) (*RecordedRequest, error) {
+	form := req.URL.Query()
+	if req.Body != nil && req.Body != http.NoBody {
+		p, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(p))
+		body, err := url.ParseQuery(string(p))
+		if err != nil {
+			return nil, err
+		}
+		for k, vs := range body {
+			form[k] = append(form[k], vs...)
+		}
+	}
+	header := janrain.RedactHeader(req.Header)
+	header.Del("Date")
+	header.Del("Accept-Encoding")
+	return &RecordedRequest{
+		Verb:   req.Method,
+		Path:   req.URL.Path,
+		Form:   janrain.Redact(form),
+		Header: header,
+	}, nil
+}
+
+// requests match if they have the same method, path and canonical form.
+func (r *RecordedRequest) matches(other *RecordedRequest) bool {
+	return r.Verb == other.Verb && r.Path == other.Path && r.Form.Encode() == other.Form.Encode()
+}
+
+func (r *RecordedResponse) response(req *http.Request) *http.Response {
+	header := make(http.Header, len(r.Header))
+	for k, vs := range r.Header {
+		header[k] = append([]string(nil), vs...)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,157 @@
+package capturetest
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"stat":"ok","result":{"uuid":%q,"accessToken":"tok"}}`, r.FormValue("uuid"))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	creds := &capture.ClientCredentials{Id: "myclient", Secret: "mysecret"}
+
+	rec, err := New(path, Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != Record {
+		t.Fatalf("unexpected mode: %v", rec.Mode())
+	}
+	client := capture.NewClient(server.URL, creds)
+	rec.Wrap(client)
+	resp, err := client.Execute("/entity", nil, capture.Params{"uuid": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Get("result").Get("accessToken").MustString() != "tok" {
+		t.Errorf("recorded response was modified: %v", resp)
+	}
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	p, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"mysecret", `"tok"`, "Signature myclient:"} {
+		if strings.Contains(string(p), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, p)
+		}
+	}
+
+	rec, err = New(path, Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != Replay {
+		t.Fatalf("unexpected mode: %v", rec.Mode())
+	}
+	client = capture.NewClient(server.URL, creds)
+	client.SetClock(capture.ClockFunc(func() time.Time { return time.Unix(0, 0) }))
+	rec.Wrap(client)
+	resp, err = client.Execute("/entity", nil, capture.Params{"uuid": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Get("result").Get("uuid").MustString() != "abc" {
+		t.Errorf("unexpected replayed response: %v", resp)
+	}
+	_, err = client.Execute("/entity", nil, capture.Params{"uuid": "abc"})
+	if err == nil {
+		t.Errorf("interaction was replayed twice")
+	}
+}
+
+// error codes survive recording so that replayed errors match error sentinels.
+func TestRecorderRemoteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"stat":"error","code":310,"error":"record_not_found","error_description":"no such entity"}`)
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	for _, mode := range []Mode{Record, Replay} {
+		rec, err := New(path, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := capture.NewClient(server.URL, nil)
+		rec.Wrap(client)
+		_, err = client.Execute("/entity", nil, capture.Params{"uuid": "missing"})
+		var rerr capture.RemoteError
+		if !errors.As(err, &rerr) || rerr.Code != 310 || !errors.Is(err, capture.ErrRecordNotFound) {
+			t.Errorf("%v: unexpected error: %#v", mode, err)
+		}
+		if mode == Record {
+			err = rec.Save()
+			if err != nil {
+				t.Fatal(err)
+			}
+			server.Close()
+		}
+	}
+}
+
+// replayed bodies match the live ones: unredacted bodies byte for byte, and
+// large ids and markup survive redaction.
+func TestRecorderBodies(t *testing.T) {
+	bodies := map[string]string{
+		"plain":  `{"stat":"ok", "result":{"id":9007199254740993,"bio":"<b>&</b>"}}`,
+		"secret": `{"stat":"ok","result":{"id":9007199254740993,"bio":"<b>&</b>","accessToken":"tok"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, bodies[r.FormValue("uuid")])
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	replayed := make(map[string]string)
+	for _, mode := range []Mode{Record, Replay} {
+		rec, err := New(path, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := capture.NewClient(server.URL, nil)
+		rec.Wrap(client)
+		for _, uuid := range []string{"plain", "secret"} {
+			var raw json.RawMessage
+			err := client.ExecuteInto("/entity", nil, capture.Params{"uuid": uuid}, &raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed[uuid] = string(raw)
+		}
+		if mode == Record {
+			err = rec.Save()
+			if err != nil {
+				t.Fatal(err)
+			}
+			server.Close()
+		}
+	}
+	if replayed["plain"] != bodies["plain"] {
+		t.Errorf("unexpected replayed body: %s", replayed["plain"])
+	}
+	for _, s := range []string{"9007199254740993", "<b>&</b>"} {
+		if !strings.Contains(replayed["secret"], s) {
+			t.Errorf("%s not replayed: %s", s, replayed["secret"])
+		}
+	}
+	if strings.Contains(replayed["secret"], `"tok"`) {
+		t.Errorf("token replayed: %s", replayed["secret"])
+	}
+}
@@ -8,56 +8,16 @@ package capture
 
 import (
 	"github.com/bitly/go-simplejson"
+	"github.com/bmatsuo1/go-janrain"
 
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
-	"net/url"
 	"strings"
-	"unicode"
+	"time"
 )
 
-func prepare(method string, uri *url.URL, header http.Header, values url.Values) (*http.Request, error) {
-	var body io.Reader
-	if method == "POST" {
-		body = strings.NewReader(values.Encode())
-		header.Set("Content-Type", "application/x-www-form-urlencoded")
-	}
-	req, err := http.NewRequest(method, uri.String(), body)
-	if err != nil {
-		return nil, err
-	}
-	for k, v := range header {
-		req.Header[k] = v
-	}
-	req.Header.Set("Accept-Encoding", "gzip")
-	return req, nil
-}
-
-// API request parameters.
-type Params map[string]interface{}
-
-func (ps Params) formValues() (url.Values, error) {
-	vals := make(url.Values, len(ps))
-	for k, v := range ps {
-		val, ok := v.(string)
-		if !ok {
-			p, err := json.Marshal(v)
-			if err != nil {
-				return nil, err
-			}
-			val = string(p)
-		}
-		vals.Set(k, val)
-	}
-	return vals, nil
-}
-
-func (ps Params) Set(key string, value interface{}) {
-	ps[key] = value
-}
-
 // an API client.
 type Client struct {
 	baseurl string
@@ -65,6 +25,14 @@ type Client struct {
 	header  http.Header
 	params  Params
 	http    *http.Client
+	clock   Clock
+	sigenc  SignatureEncoding
+	verbs   map[string]string
+	log     func(*CallEvent)
+	app     string
+	metrics Metrics
+	tracer  Tracer
+	ctx     context.Context
 }
 
 // construct a new API client. though auth can be nil it is generally
@@ -77,6 +45,7 @@ func NewClient(baseurl string, auth Authorization) *Client {
 		params:  make(Params),
 		header:  make(http.Header),
 		http:    new(http.Client),
+		clock:   SystemClock,
 	}
 	return client
 }
@@ -86,6 +55,11 @@ func (client *Client) Execute(method string, header http.Header, params Params)
 	return client.ExecuteAuth(client.auth, method, header, params)
 }
 
+// the Authorization used to initialize the client.
+func (client *Client) Auth() Authorization {
+	return client.auth
+}
+
 // a set of params sent with every API call.
 func (client *Client) Params() Params {
 	return client.params
@@ -96,36 +70,199 @@ func (client *Client) Header() http.Header {
 	return client.header
 }
 
+// the http client used to send requests.
+func (client *Client) HttpClient() *http.Client {
+	return client.http
+}
+
+// set the http client used to send requests, e.g. to use a custom transport.
+func (client *Client) SetHttpClient(c *http.Client) {
+	client.http = c
+}
+
+// set the clock used to authorize requests with a TimedAuthorization, such as
+// ClientCredentials.
+func (client *Client) SetClock(clock Clock) {
+	client.clock = clock
+}
+
+// set the encoding of parameters in signatures made by ClientCredentials.
+// the default, SignRaw, is the encoding Capture verifies.
+func (client *Client) SetSignatureEncoding(enc SignatureEncoding) {
+	client.sigenc = enc
+}
+
+// the current time according to the client's clock.
+func (client *Client) now() time.Time {
+	if client == nil || client.clock == nil {
+		return time.Now()
+	}
+	return client.clock.Now()
+}
+
 // execute an API call with an Authorization that overrides the value used to
 // initialize the client.
 func (client *Client) ExecuteAuth(auth Authorization, method string, header http.Header, params Params) (*simplejson.Json, error) {
-	uri, header, values, err := client.merge(method, header, params)
+	return client.ExecuteVerb(auth, client.verb(method, "POST"), method, header, params)
+}
+
+// like ExecuteAuth but with an explicit http method, "GET" or "POST". GET
+// requests send parameters in the query string.
+func (client *Client) ExecuteVerb(auth Authorization, verb, method string, header http.Header, params Params) (*simplejson.Json, error) {
+	_, js, err := client.call(auth, verb, method, header, params, client.perform)
+	return js, err
+}
+
+// set the http method, "GET" or "POST", used for calls to an API method that
+// are not given one explicitly. calls made with the Execute* methods default
+// to POST. typed read methods such as Entity and EntityCount default to GET.
+func (client *Client) SetVerb(method, verb string) {
+	if client.verbs == nil {
+		client.verbs = make(map[string]string)
+	}
+	client.verbs[methodKey(method)] = verb
+}
+
+// the http method to use for an API method, given its default.
+func (client *Client) verb(method, _default string) string {
+	if verb, ok := client.verbs[methodKey(method)]; ok {
+		return verb
+	}
+	return _default
+}
+
+func methodKey(method string) string {
+	if strings.HasPrefix(method, "/") {
+		return method
+	}
+	return "/" + method
+}
+
+// execute an API call with the Authorization used to initialize the client
+// and decode the response into out using encoding/json. out is typically a
+// pointer to a struct with fields for the parts of the response the caller
+// needs. a response value whose type does not match the corresponding field of
+// out results in a JsonDecoderError.
+func (client *Client) ExecuteInto(method string, header http.Header, params Params, out interface{}) error {
+	return client.ExecuteAuthInto(client.auth, method, header, params, out)
+}
+
+// like ExecuteInto but with an Authorization that overrides the value used to
+// initialize the client.
+func (client *Client) ExecuteAuthInto(auth Authorization, method string, header http.Header, params Params, out interface{}) error {
+	return client.executeInto(auth, client.verb(method, "POST"), method, header, params, out)
+}
+
+func (client *Client) executeInto(auth Authorization, verb, method string, header http.Header, params Params, out interface{}) error {
+	r, _, err := client.call(auth, verb, method, header, params, client.perform)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	err = json.Unmarshal(r.Body, out)
+	if err != nil {
+		return JsonDecoderError{HttpResponseData: r, Err: err}
 	}
+	return nil
+}
+
+// execute an API call with the Authorization used to initialize the client,
+// passing each element of the response's "results" array to fn as it is read
+// instead of holding the entire response in memory. the response without its
+// results is returned. if fn returns an error the call is abandoned and the
+// error is returned.
+func (client *Client) ExecuteResults(method string, header http.Header, params Params, fn func(result json.RawMessage) error) (*simplejson.Json, error) {
+	return client.ExecuteAuthResults(client.auth, method, header, params, fn)
+}
+
+// like ExecuteResults but with an Authorization that overrides the value used
+// to initialize the client.
+func (client *Client) ExecuteAuthResults(auth Authorization, method string, header http.Header, params Params, fn func(result json.RawMessage) error) (*simplejson.Json, error) {
+	return client.executeResults(auth, client.verb(method, "POST"), method, header, params, fn)
+}
 
-	if auth != nil {
-		err = auth.Authorize(uri, header, values)
+func (client *Client) executeResults(auth Authorization, verb, method string, header http.Header, params Params, fn func(result json.RawMessage) error) (*simplejson.Json, error) {
+	_, js, err := client.call(auth, verb, method, header, params, func(req *janrain.Request) (*HttpResponseData, *simplejson.Json, error) {
+		return janrain.PerformStream(client.http, req, "results", fn, remoteError)
+	})
+	return js, err
+}
+
+// perform an API call. calls authorized by a TokenSource are retried once if
+// Capture rejects the access token as expired.
+func (client *Client) call(auth Authorization, verb, method string, header http.Header, params Params, perform performFunc) (*HttpResponseData, *simplejson.Json, error) {
+	ctx, end := client.startSpan(verb, method, params)
+	r, js, err := client.callContext(ctx, auth, verb, method, header, params, perform)
+	end(r, js, err)
+	return r, js, err
+}
+
+func (client *Client) callContext(ctx context.Context, auth Authorization, verb, method string, header http.Header, params Params, perform performFunc) (*HttpResponseData, *simplejson.Json, error) {
+	perform = client.observe(perform)
+	request := func() (*janrain.Request, error) {
+		req, err := client.request(auth, verb, method, header, params)
 		if err != nil {
 			return nil, err
 		}
+		req.Context = ctx
+		client.inject(ctx, req.Header)
+		return req, nil
 	}
-
-	req, err := prepare("POST", uri, header, values)
-	return client.perform(req)
+	req, err := request()
+	if err != nil {
+		return nil, nil, err
+	}
+	r, js, err := perform(req)
+	if src, ok := auth.(*TokenSource); ok && errors.Is(err, ErrAccessTokenExpired) {
+		// the access token was revoked or expired earlier than expected.
+		if client.metrics != nil {
+			client.metrics.Retry(client.app, methodKey(method), ErrAccessTokenExpired.Kind())
+		}
+		err = src.expire(req.Header)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err = request()
+		if err != nil {
+			return nil, nil, err
+		}
+		r, js, err = perform(req)
+	}
+	if err != nil {
+		return r, nil, err
+	}
+	return r, js, nil
 }
 
-func (client *Client) merge(method string, header http.Header, params Params) (*url.URL, http.Header, url.Values, error) {
-	endpoint := client.baseurl
-	if method[0] != '/' {
-		endpoint += "/"
+// construct an authorized request for an API call.
+func (client *Client) request(auth Authorization, verb, method string, header http.Header, params Params) (*janrain.Request, error) {
+	if _, ok := auth.(*ClientCredentialsSimple); ok {
+		// never put a client secret in a url.
+		verb = "POST"
 	}
-	endpoint += method
-	uri, err := url.Parse(endpoint)
+	header, params = client.merge(header, params)
+	req, err := janrain.NewRequest(verb, client.baseurl, method, header, params)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
+	switch auth := auth.(type) {
+	case nil:
+	case *ClientCredentials:
+		err = auth.AuthorizeEncoding(client.sigenc, client.now(), req.URL, req.Header, req.Values)
+	case TimedAuthorization:
+		err = auth.AuthorizeTime(client.now(), req.URL, req.Header, req.Values)
+	default:
+		err = req.Authorize(auth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// combine the client's header and params with those of a call. values given
+// for the call take precedence.
+func (client *Client) merge(header http.Header, params Params) (http.Header, Params) {
 	mergedheader := make(http.Header)
 	for k, v := range client.header {
 		mergedheader[k] = v
@@ -141,41 +278,18 @@ func (client *Client) merge(method string, header http.Header, params Params) (*
 	for k, v := range params {
 		mergedparams[k] = v
 	}
-	values, err := mergedparams.formValues()
-	if err != nil {
-		return nil, nil, nil, err
-	}
 
-	return uri, mergedheader, values, nil
+	return mergedheader, mergedparams
 }
 
-func contentType(resp *http.Response) string {
-	v := resp.Header.Get("Content-Type")
-	return strings.TrimFunc(strings.SplitN(v, ";", 2)[0], unicode.IsSpace)
+// sends a request and decodes its response.
+type performFunc func(req *janrain.Request) (*HttpResponseData, *simplejson.Json, error)
+
+func (client *Client) perform(req *janrain.Request) (*HttpResponseData, *simplejson.Json, error) {
+	return janrain.Perform(client.http, req, remoteError)
 }
 
-func (client *Client) perform(req *http.Request) (*simplejson.Json, error) {
-	resp, err := client.http.Do(req)
-	if err != nil {
-		return nil, HttpTransportError{err}
-	}
-	r, err := ReadResponse(resp)
-	if err != nil {
-		// this could include some extra information
-		return nil, HttpTransportError{fmt.Errorf("unable to read http response: %v", err)}
-	}
-	switch mime := contentType(resp); mime {
-	case "application/json", "text/json":
-		js := new(simplejson.Json)
-		err := json.Unmarshal(r.Body, js)
-		if err != nil {
-			return nil, JsonDecoderError{r, err}
-		}
-		if js.Get("stat").MustString() != "ok" {
-			return nil, NewRemoteError(resp, js)
-		}
-		return js, nil
-	default:
-		return nil, &ContentTypeError{r}
-	}
+// a janrain.ErrorFunc for Capture responses.
+func remoteError(req *HttpRequestData, r *HttpResponseData, js *simplejson.Json) error {
+	return newRemoteError(req, r, js)
 }
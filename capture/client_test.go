@@ -0,0 +1,330 @@
+package capture
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func testServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestExecuteInto(t *testing.T) {
+	var method, uuid string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, uuid = r.Method, r.FormValue("uuid")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"stat":"ok","result":{"uuid":"abc","givenName":"Bob","birthday":7}}`)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	var user struct {
+		Uuid      string `json:"uuid"`
+		GivenName string `json:"givenName"`
+	}
+	err := client.Entity("user", "abc", nil, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Uuid != "abc" || user.GivenName != "Bob" {
+		t.Errorf("unexpected entity: %#v", user)
+	}
+	if method != "GET" || uuid != "abc" {
+		t.Errorf("unexpected request: %s uuid=%q", method, uuid)
+	}
+
+	var mismatch struct {
+		Result struct {
+			Birthday string `json:"birthday"`
+		} `json:"result"`
+	}
+	err = client.ExecuteInto("/entity", nil, nil, &mismatch)
+	if _, ok := err.(JsonDecoderError); !ok {
+		t.Errorf("unexpected error for mismatched type: %v", err)
+	}
+	if method != "POST" {
+		t.Errorf("unexpected method: %s", method)
+	}
+}
+
+func TestExecuteIntoRemoteError(t *testing.T) {
+	const body = `{"stat":"error", "code":310, "error":"record_not_found", "error_description":"no such entity"}`
+	server := testServer(body)
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	var user struct{}
+	err := client.Entity("user", "abc", Params{"access_token": "secret"}, &user)
+	rerr, ok := err.(RemoteError)
+	if !ok || rerr.Code != 310 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(rerr.HttpResponse().Body) != body {
+		t.Errorf("unexpected error body: %q", rerr.HttpResponse().Body)
+	}
+	req := rerr.HttpRequest()
+	if req == nil || req.Method != "/entity" || req.Verb != "GET" {
+		t.Fatalf("unexpected error request: %#v", req)
+	}
+	if req.Params.Get("uuid") != "abc" || req.Params.Get("access_token") != "REDACTED" {
+		t.Errorf("unexpected error request params: %v", req.Params)
+	}
+}
+
+func TestTransportErrorRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html>oops</html>")
+	}))
+	client := NewClient(server.URL, nil)
+	_, err := client.Execute("/entity", nil, Params{"access_token": "secret"})
+	cterr, ok := err.(*ContentTypeError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req := cterr.HttpRequest(); req == nil || req.Method != "/entity" || req.Params.Get("access_token") != "REDACTED" {
+		t.Errorf("unexpected error request: %#v", req)
+	}
+
+	server.Close()
+	_, err = client.Execute("/entity", nil, nil)
+	terr, ok := err.(HttpTransportError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req := terr.HttpRequest(); req == nil || req.Method != "/entity" {
+		t.Errorf("unexpected error request: %#v", req)
+	}
+	if terr.HttpResponse() != nil {
+		t.Errorf("unexpected error response: %v", terr.HttpResponse())
+	}
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		t.Errorf("%v does not wrap its cause", err)
+	}
+}
+
+func TestExecuteResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, `{"results":[{"id":1},{"id":2},{"id":3}],"result_count":3,"stat":"ok"}`)
+		gz.Close()
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	var ids []int
+	n, err := client.EntityFindEach("user", nil, func(entity json.RawMessage) error {
+		var e struct{ Id int }
+		err := json.Unmarshal(entity, &e)
+		ids = append(ids, e.Id)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("unexpected results: %d %v", n, ids)
+	}
+
+	stop := fmt.Errorf("stop")
+	_, err = client.EntityFindEach("user", nil, func(entity json.RawMessage) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// errors from streamed calls carry the body as received, less the streamed
+// elements.
+func TestExecuteResultsError(t *testing.T) {
+	server := testServer(`{"stat": "error", "results": [{"id":1}, {"id":2}], "code": 310, "error": "record_not_found"}`)
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+
+	n := 0
+	_, err := client.EntityFindEach("user", nil, func(json.RawMessage) error { n++; return nil })
+	var rerr RemoteError
+	if !errors.As(err, &rerr) || rerr.Code != 310 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `{"stat": "error", "results": [], "code": 310, "error": "record_not_found"}`
+	if body := string(rerr.HttpResponse().Body); n != 2 || body != expect {
+		t.Errorf("unexpected error body after %d results: %s", n, body)
+	}
+}
+
+func TestLogHook(t *testing.T) {
+	const body = `{"stat":"error","code":310,"error":"record_not_found","request_id":"r1"}`
+	server := testServer(body)
+	defer server.Close()
+	client := NewClient(server.URL, AccessToken("abc123"))
+	var events []*CallEvent
+	client.SetLogHook(func(event *CallEvent) { events = append(events, event) })
+
+	client.Execute("/entity", nil, Params{"client_secret": "shh", "type_name": "user"})
+	if len(events) != 1 {
+		t.Fatalf("unexpected number of events: %d", len(events))
+	}
+	event := events[0]
+	if event.Method != "/entity" || event.Status != 200 || event.RequestId != "r1" {
+		t.Errorf("unexpected event: %#v", event)
+	}
+	if event.ErrorKind != "record_not_found" {
+		t.Errorf("unexpected error kind: %q", event.ErrorKind)
+	}
+	if event.Params.Get("client_secret") != "REDACTED" || event.Params.Get("type_name") != "user" {
+		t.Errorf("unexpected params: %v", event.Params)
+	}
+	if auth := event.Header.Get("Authorization"); auth != "OAuth REDACTED" {
+		t.Errorf("unexpected authorization: %q", auth)
+	}
+
+	if event.redacted != nil {
+		t.Errorf("body redacted before it was requested")
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	SlogHook(logger)(event)
+	if out := buf.String(); strings.Contains(out, "abc123") || strings.Contains(out, "shh") {
+		t.Errorf("secret logged: %s", out)
+	} else if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "call.request_id=r1") || !strings.Contains(out, "body=") {
+		t.Errorf("unexpected log: %s", out)
+	}
+}
+
+// responses that cannot be decompressed are reported as encoding errors.
+func TestLogHookEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "bogus")
+		fmt.Fprint(w, `{"stat":"ok"}`)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+	var events []*CallEvent
+	client.SetLogHook(func(event *CallEvent) { events = append(events, event) })
+
+	_, err := client.Execute("/entity", nil, nil)
+	if err == nil {
+		t.Fatal("no error for an unknown encoding")
+	}
+	if len(events) != 1 || events[0].ErrorKind != "encoding" {
+		t.Errorf("unexpected events: %#v", events)
+	}
+}
+
+// tokens issued by /oauth/token are not logged.
+func TestLogHookRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth/token":
+			fmt.Fprint(w, `{"stat":"ok","access_token":"at-secret-2","refresh_token":"rt-secret-2","expires_in":3600}`)
+		default:
+			fmt.Fprint(w, `{"stat":"ok","result":{}}`)
+		}
+	}))
+	defer server.Close()
+	creds := &ClientCredentials{"id", "secret"}
+	client := NewClient(server.URL, creds)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client.SetLogHook(SlogHook(logger))
+	src := NewTokenSource(client, creds, Token{AccessToken: "at-secret-1", RefreshToken: "rt-secret-1"})
+
+	err := src.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ExecuteAuth(src, "/entity", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "capture /oauth/token") || !strings.Contains(out, "body=") {
+		t.Errorf("unexpected log: %s", out)
+	}
+	for _, secret := range []string{"at-secret", "rt-secret", "client_secret=secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%s logged: %s", secret, out)
+		}
+	}
+}
+
+type testSpan struct {
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (span *testSpan) SetAttribute(key string, value interface{}) { span.attrs[key] = value }
+func (span *testSpan) RecordError(err error)                      { span.err = err }
+func (span *testSpan) End()                                       { span.ended = true }
+
+type testTracer struct{ spans []*testSpan }
+
+func (tracer *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{attrs: map[string]interface{}{"name": name}}
+	tracer.spans = append(tracer.spans, span)
+	return ctx, span
+}
+
+func (tracer *testTracer) Inject(ctx context.Context, header http.Header) {
+	header.Set("Traceparent", "00-abc-def-01")
+}
+
+func TestTracer(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"stat":"error","code":310,"error":"record_not_found","request_id":"r1"}`)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+	tracer := new(testTracer)
+	client.SetTracer(tracer)
+
+	client.WithContext(context.Background()).Execute("entity", nil, Params{"type_name": "user"})
+	if len(tracer.spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if !span.ended || span.err == nil {
+		t.Errorf("span was not ended with an error: %#v", span)
+	}
+	for key, value := range map[string]interface{}{
+		"name":         "capture /entity",
+		AttrMethod:     "/entity",
+		AttrEntityType: "user",
+		AttrAppDomain:  strings.TrimPrefix(server.URL, "http://"),
+		AttrRequestId:  "r1",
+		AttrErrorKind:  "record_not_found",
+	} {
+		if span.attrs[key] != value {
+			t.Errorf("unexpected %s: %v", key, span.attrs[key])
+		}
+	}
+	if traceparent != "00-abc-def-01" {
+		t.Errorf("trace context was not propagated: %q", traceparent)
+	}
+}
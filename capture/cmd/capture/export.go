@@ -0,0 +1,98 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// export.go [created: Sun, 18 Oct 2026]
+
+package main
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture/bulk"
+	"github.com/bmatsuo1/go-janrain/capture/config"
+	"github.com/bmatsuo1/go-janrain/capture/filter"
+
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func init() {
+	commands["export"] = &command{"write entities to a file as NDJSON or CSV", export}
+}
+
+func export(conf *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	app := fs.String("app", "", "app name in the config file")
+	typeName := fs.String("type", "user", "entity type")
+	filterStr := fs.String("filter", "", "entity filter")
+	attrs := fs.String("attributes", "", "comma separated attributes to export (default all)")
+	pageSize := fs.Int("page-size", bulk.DefaultPageSize, "entities per call to /entity.find")
+	format := fs.String("format", "ndjson", "output format, ndjson or csv")
+	plurals := fs.String("plurals", "json", "plural handling, json, indexed or skip")
+	columns := fs.String("columns", "", "comma separated CSV columns (default from the first page)")
+	output := fs.String("o", "", "output file (default stdout)")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file for resuming an interrupted export")
+	fs.Parse(args)
+
+	opts := bulk.ExportOptions{
+		TypeName:   *typeName,
+		Attributes: list(*attrs),
+		PageSize:   *pageSize,
+		Columns:    list(*columns),
+		Checkpoint: *checkpoint,
+	}
+	if *filterStr != "" {
+		opts.Filter = filter.Filter(*filterStr)
+	}
+	switch *format {
+	case "ndjson":
+		opts.Format = bulk.NDJSON
+	case "csv":
+		opts.Format = bulk.CSV
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	switch *plurals {
+	case "json":
+		opts.Plurals = bulk.PluralJSON
+	case "indexed":
+		opts.Plurals = bulk.PluralIndexed
+	case "skip":
+		opts.Plurals = bulk.PluralSkip
+	default:
+		return fmt.Errorf("unknown plural handling %q", *plurals)
+	}
+
+	c, err := client(conf, *app)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		// a resumed export appends to the interrupted export's output.
+		mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if *checkpoint != "" {
+			cp, err := bulk.ReadCheckpoint(*checkpoint)
+			if err != nil {
+				return err
+			}
+			if cp.Count > 0 {
+				mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+		}
+		f, err := os.OpenFile(*output, mode, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	cp, err := bulk.Export(c, w, opts)
+	if cp != nil {
+		fmt.Fprintf(os.Stderr, "exported %d entities (last id %d)\n", cp.Count, cp.LastId)
+	}
+	return err
+}
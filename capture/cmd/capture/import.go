@@ -0,0 +1,103 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// import.go [created: Sun, 18 Oct 2026]
+
+package main
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture/bulk"
+	"github.com/bmatsuo1/go-janrain/capture/config"
+
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	commands["import"] = &command{"create entities from an NDJSON or CSV file", importCmd}
+}
+
+func importCmd(conf *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	app := fs.String("app", "", "app name in the config file")
+	typeName := fs.String("type", "user", "entity type")
+	format := fs.String("format", "ndjson", "input format, ndjson or csv")
+	mapping := fs.String("map", "", "comma separated column=attribute pairs (default columns are attributes)")
+	batchSize := fs.Int("batch-size", bulk.DefaultBatchSize, "entities per call to /entity.bulkCreate")
+	concurrency := fs.Int("concurrency", 1, "concurrent calls to /entity.bulkCreate")
+	noValidate := fs.Bool("no-validate", false, "send records without validating them against the schema")
+	results := fs.String("results", "", "file receiving per-record results as NDJSON (default stdout)")
+	failed := fs.String("failed", "", "file receiving failed records in the input format, to be corrected and imported again")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: capture import [FLAGS] [FILE]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := bulk.ImportOptions{
+		TypeName:    *typeName,
+		BatchSize:   *batchSize,
+		Concurrency: *concurrency,
+		NoValidate:  *noValidate,
+		Results:     os.Stdout,
+	}
+	switch *format {
+	case "ndjson":
+		opts.Format = bulk.NDJSON
+	case "csv":
+		opts.Format = bulk.CSV
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *mapping != "" {
+		opts.Mapping = make(map[string]string)
+		for _, pair := range list(*mapping) {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid mapping %q", pair)
+			}
+			opts.Mapping[kv[0]] = kv[1]
+		}
+	}
+
+	c, err := client(conf, *app)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *results != "" {
+		f, err := os.Create(*results)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts.Results = f
+	}
+	if *failed != "" {
+		f, err := os.Create(*failed)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts.Failed = f
+	}
+
+	summary, err := bulk.Import(c, r, opts)
+	if summary != nil {
+		fmt.Fprintf(os.Stderr, "read %d records: %d created, %d failed\n", summary.Records, summary.Created, summary.Failed)
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// main.go [created: Sun, 18 Oct 2026]
+
+/*
+Command capture performs administrative tasks against Capture apps defined in a
+config file (see package capture/config).
+
+	capture [-config FILE] COMMAND [FLAGS]
+
+Commands
+
+	export    write entities to a file as NDJSON or CSV
+	import    create entities from an NDJSON or CSV file
+	settings  compare settings with a settings file and apply the difference
+
+Run a command with -h for its flags.
+*/
+package main
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+	"github.com/bmatsuo1/go-janrain/capture/config"
+
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// a subcommand. args do not include the command name.
+type command struct {
+	usage string
+	run   func(conf *config.Config, args []string) error
+}
+
+var commands = map[string]*command{}
+
+func main() {
+	home, _ := os.UserHomeDir()
+	confpath := flag.String("config", filepath.Join(home, ".capture.json"), "config file")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	cmd := commands[flag.Arg(0)]
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	conf, err := config.ReadFileJSON(*confpath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	err = cmd.run(conf, flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [-config FILE] COMMAND [FLAGS]\n\n", filepath.Base(os.Args[0]))
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-10s%s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
+
+// a client for the app named by the -app flag.
+func client(conf *config.Config, app string) (*capture.Client, error) {
+	if app == "" {
+		if len(conf.Apps) != 1 {
+			return nil, fmt.Errorf("-app is required")
+		}
+		for name := range conf.Apps {
+			app = name
+		}
+	}
+	return conf.NewClient(app, nil)
+}
+
+// a comma separated list flag value. an empty string is a nil list.
+func list(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,63 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// settings.go [created: Sun, 18 Oct 2026]
+
+package main
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture/config"
+
+	"flag"
+	"fmt"
+	"os"
+)
+
+func init() {
+	commands["settings"] = &command{"compare settings with a settings file and apply the difference", settings}
+}
+
+func settings(conf *config.Config, args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ExitOnError)
+	app := fs.String("app", "", "app name in the config file")
+	apply := fs.Bool("apply", false, "apply the difference (default only print it)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: capture settings [FLAGS] FILE")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	desired, err := config.ReadSettingsFileJSON(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c, err := client(conf, *app)
+	if err != nil {
+		return err
+	}
+	changes, err := desired.Diff(c)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "settings are up to date")
+		return nil
+	}
+	if !*apply {
+		return nil
+	}
+	err = config.Apply(c, changes)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "applied %d changes\n", len(changes))
+	return nil
+}
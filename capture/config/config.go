@@ -6,7 +6,7 @@
 
 /*
 This package defines a configuration file format meant to house many client ids.
-The idea was to have a new CLI for capture. But one does not exist.
+It is read by the capture command (capture/cmd/capture).
 */
 package config
 
@@ -14,8 +14,10 @@ import (
 	"github.com/bmatsuo1/go-janrain/capture"
 
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 type Config struct {
@@ -34,6 +36,36 @@ type AppConfig struct {
 type CLIConfig struct {
 }
 
+// construct a client for the named app authorized by its default client.
+// credentials are looked up in the app's clients then in the config's. if m
+// is not nil the client reports metrics to m labeled with app.
+func (config *Config) NewClient(app string, m capture.Metrics) (*capture.Client, error) {
+	appconfig := config.Apps[app]
+	if appconfig == nil {
+		return nil, fmt.Errorf("unknown app %q", app)
+	}
+	creds := appconfig.Clients[appconfig.DefaultClient]
+	if creds == nil {
+		creds = config.Clients[appconfig.DefaultClient]
+	}
+	if creds == nil {
+		return nil, fmt.Errorf("unknown client %q for app %q", appconfig.DefaultClient, app)
+	}
+	client := capture.NewClient(appconfig.BaseURL(), creds)
+	if m != nil {
+		client.SetMetrics(app, m)
+	}
+	return client, nil
+}
+
+// the base url of the app's API. a domain without a scheme uses https.
+func (app *AppConfig) BaseURL() string {
+	if strings.Contains(app.Domain, "://") {
+		return app.Domain
+	}
+	return "https://" + app.Domain
+}
+
 type indentedJSON struct {
 	val    interface{}
 	prefix string
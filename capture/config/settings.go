@@ -0,0 +1,192 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// settings.go [created: Sun, 18 Oct 2026]
+
+package config
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// the desired settings of an app, kept under version control and applied with
+// Apply.
+//
+//	{
+//		"defaults": {"password_recover_url": "https://example.com/reset"},
+//		"clients": {
+//			"myclientid": {"rpx_key": "..."}
+//		},
+//		"prune": false
+//	}
+type SettingsConfig struct {
+	// app-wide settings.
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	// settings of API clients, by client id.
+	Clients map[string]map[string]string `json:"clients,omitempty"`
+
+	// if true client settings not in the file are deleted. otherwise they are
+	// left alone. a client's settings are only pruned if the client is listed.
+	// settings a client inherits from the app-wide settings, and app-wide
+	// settings themselves, are never pruned.
+	Prune bool `json:"prune,omitempty"`
+}
+
+// a difference between desired and live settings.
+type SettingChange struct {
+	ClientId string // empty for app-wide settings
+	Key      string
+	Old      *string // nil if the setting does not exist
+	New      *string // nil if the setting is deleted
+}
+
+func (c SettingChange) String() string {
+	scope := "defaults"
+	if c.ClientId != "" {
+		scope = "client " + c.ClientId
+	}
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("+ %s %s: %q", scope, c.Key, *c.New)
+	case c.New == nil:
+		return fmt.Sprintf("- %s %s: %q", scope, c.Key, *c.Old)
+	}
+	return fmt.Sprintf("~ %s %s: %q -> %q", scope, c.Key, *c.Old, *c.New)
+}
+
+// the changes needed to make the live settings of client's app match config.
+func (config *SettingsConfig) Diff(client *capture.Client) ([]SettingChange, error) {
+	changes, err := diffSettings(client.Settings(), config.Defaults, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(config.Clients))
+	for id := range config.Clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		_changes, err := diffSettings(client.ClientSettings(id), config.Clients[id], client.Settings(), config.Prune)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, _changes...)
+	}
+	return changes, nil
+}
+
+// the changes to settings needed to match desired. settings whose values are
+// inherited from defaults are not pruned.
+func diffSettings(settings *capture.Settings, desired map[string]string, defaults *capture.Settings, prune bool) ([]SettingChange, error) {
+	var changes []SettingChange
+	live := make(map[string]string)
+	if settings.ClientId() == "" {
+		// app-wide items may include settings of the calling client, so
+		// defaults are read one at a time.
+		for key := range desired {
+			value, ok, err := settings.Get(key)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				live[key] = value
+			}
+		}
+	} else {
+		items, err := settings.Items()
+		if err != nil {
+			return nil, err
+		}
+		live = items
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_new := desired[key]
+		old, ok := live[key]
+		switch {
+		case !ok:
+			changes = append(changes, SettingChange{settings.ClientId(), key, nil, &_new})
+		case old != _new:
+			changes = append(changes, SettingChange{settings.ClientId(), key, &old, &_new})
+		}
+	}
+	if prune {
+		var extra []string
+		for key := range live {
+			if _, ok := desired[key]; !ok {
+				extra = append(extra, key)
+			}
+		}
+		sort.Strings(extra)
+		for _, key := range extra {
+			old := live[key]
+			inherited, ok, err := defaults.Get(key)
+			if err != nil {
+				return nil, err
+			}
+			if ok && inherited == old {
+				continue
+			}
+			changes = append(changes, SettingChange{settings.ClientId(), key, &old, nil})
+		}
+	}
+	return changes, nil
+}
+
+// make the changes to the live settings of client's app. changes are made in
+// order and the first error stops the application of further changes.
+func Apply(client *capture.Client, changes []SettingChange) error {
+	for _, c := range changes {
+		settings := client.Settings()
+		if c.ClientId != "" {
+			settings = client.ClientSettings(c.ClientId)
+		}
+		var err error
+		if c.New == nil {
+			_, err = settings.Delete(c.Key)
+		} else {
+			_, err = settings.Set(c.Key, *c.New)
+		}
+		if err != nil {
+			return fmt.Errorf("%v: %v", c, err)
+		}
+	}
+	return nil
+}
+
+func WriteSettingsJSON(w io.Writer, config *SettingsConfig) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(newIndentedJSON(config))
+}
+
+func ReadSettingsJSON(r io.Reader) (*SettingsConfig, error) {
+	config := new(SettingsConfig)
+	dec := json.NewDecoder(r)
+	err := dec.Decode(config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func ReadSettingsFileJSON(filename string) (*SettingsConfig, error) {
+	handle, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+	return ReadSettingsJSON(handle)
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serves the /settings API from maps of app-wide and client settings, recording
+// the calls that change settings.
+func settingsServer(t *testing.T, defaults map[string]string, clients map[string]map[string]string, calls *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		settings := defaults
+		id := r.FormValue("for_client_id")
+		if strings.HasSuffix(r.URL.Path, "_default") && id != "" {
+			t.Errorf("%s called for client %q", r.URL.Path, id)
+		}
+		if id != "" {
+			if clients[id] == nil {
+				clients[id] = make(map[string]string)
+			}
+			settings = clients[id]
+		}
+		key := r.FormValue("key")
+		var result interface{}
+		switch r.URL.Path {
+		case "/settings/items":
+			items := make(map[string]string)
+			for k, v := range defaults {
+				items[k] = v
+			}
+			for k, v := range settings {
+				items[k] = v
+			}
+			result = items
+		case "/settings/get":
+			if v, ok := settings[key]; ok {
+				result = v
+			}
+		case "/settings/set", "/settings/set_default":
+			*calls = append(*calls, r.URL.Path+" "+id+" "+key)
+			_, result = settings[key]
+			settings[key] = r.FormValue("value")
+		case "/settings/delete", "/settings/delete_default":
+			*calls = append(*calls, r.URL.Path+" "+id+" "+key)
+			_, result = settings[key]
+			delete(settings, key)
+		default:
+			t.Errorf("undocumented call: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"stat": "ok", "result": result})
+	}))
+}
+
+func TestSettingsApply(t *testing.T) {
+	defaults := map[string]string{"a": "1", "b": "2", "unmanaged": "x"}
+	clients := map[string]map[string]string{"c1": {"b": "3", "old": "y"}}
+	var calls []string
+	server := settingsServer(t, defaults, clients, &calls)
+	defer server.Close()
+	client := capture.NewClient(server.URL, nil)
+
+	desired := &SettingsConfig{
+		Defaults: map[string]string{"a": "1", "b": "20", "c": "4"},
+		Clients:  map[string]map[string]string{"c1": {"b": "3"}},
+		Prune:    true,
+	}
+	changes, err := desired.Diff(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	expect := `~ defaults b: "2" -> "20"
++ defaults c: "4"
+- client c1 old: "y"`
+	if got := strings.Join(lines, "\n"); got != expect {
+		t.Errorf("unexpected changes:\n%s", got)
+	}
+
+	err = Apply(client, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(defaults) != "map[a:1 b:20 c:4 unmanaged:x]" || fmt.Sprint(clients["c1"]) != "map[b:3]" {
+		t.Errorf("unexpected settings: %v %v", defaults, clients)
+	}
+	expectCalls := []string{
+		"/settings/set_default  b",
+		"/settings/set_default  c",
+		"/settings/delete c1 old",
+	}
+	if !reflect.DeepEqual(calls, expectCalls) {
+		t.Errorf("unexpected calls: %q", calls)
+	}
+
+	value, ok, err := capture.NewClient(server.URL, nil).Settings().Get("a")
+	if err != nil || !ok || value != "1" {
+		t.Errorf("unexpected app-wide setting: %q %v %v", value, ok, err)
+	}
+	changes, err = desired.Diff(client)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("unexpected changes after apply: %v %v", changes, err)
+	}
+}
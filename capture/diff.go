@@ -0,0 +1,196 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// diff.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// attributes maintained by Capture. they are never included in a Patch.
+var ReadOnlyAttributes = map[string]bool{
+	"id":          true,
+	"uuid":        true,
+	"created":     true,
+	"lastUpdated": true,
+}
+
+// the kind of a Change.
+type ChangeOp byte
+
+const (
+	Added    ChangeOp = '+'
+	Removed  ChangeOp = '-'
+	Modified ChangeOp = '~'
+)
+
+// a change to an attribute. plural elements are identified in paths by id
+// (e.g. "profiles#12.domain"). elements without an id are identified as "#new".
+type Change struct {
+	Op       ChangeOp
+	Path     string
+	Old, New interface{}
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case Added:
+		return fmt.Sprintf("+ %s: %v", c.Path, jsonStringer(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %v", c.Path, jsonStringer(c.Old))
+	}
+	return fmt.Sprintf("~ %s: %v -> %v", c.Path, jsonStringer(c.Old), jsonStringer(c.New))
+}
+
+// the calls needed to make one entity look like another.
+type Patch struct {
+	// the "value" of an /entity.update call, or nil if no attributes
+	// changed. plural elements with an id update the existing element and
+	// elements without one are appended.
+	Attributes map[string]interface{}
+
+	// the "attribute_name" of plural elements to remove with /entity.delete
+	// (e.g. "profiles#12").
+	Removed []string
+
+	// all changes, sorted by path.
+	Changes []Change
+}
+
+// true if the entities compared were the same.
+func (p *Patch) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// a human readable diff, one change per line.
+func (p *Patch) String() string {
+	lines := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compare two versions of an entity, producing the minimal update from old to
+// _new. attributes missing from _new are left unchanged; to clear an
+// attribute give it a null value. nested objects are compared attribute by
+// attribute and plural elements are matched by their ids. attributes in
+// ReadOnlyAttributes are ignored.
+func Diff(old, _new map[string]interface{}) *Patch {
+	p := new(Patch)
+	attrs := p.diffObject("", old, _new)
+	if len(attrs) > 0 {
+		p.Attributes = attrs
+	}
+	sort.SliceStable(p.Changes, func(i, j int) bool { return p.Changes[i].Path < p.Changes[j].Path })
+	sort.Strings(p.Removed)
+	return p
+}
+
+// the changed attributes of an object.
+func (p *Patch) diffObject(prefix string, old, _new map[string]interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	for name, nv := range _new {
+		if ReadOnlyAttributes[name] {
+			continue
+		}
+		path := prefix + name
+		ov, ok := old[name]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Added, path, nil, nv})
+			attrs[name] = nv
+			continue
+		}
+		oobj, oisobj := ov.(map[string]interface{})
+		nobj, nisobj := nv.(map[string]interface{})
+		switch {
+		case oisobj && nisobj:
+			if sub := p.diffObject(path+".", oobj, nobj); len(sub) > 0 {
+				attrs[name] = sub
+			}
+		case isPlural(ov) && isPlural(nv):
+			if elems := p.diffPlural(path, ov.([]interface{}), nv.([]interface{})); len(elems) > 0 {
+				attrs[name] = elems
+			}
+		case !equalJSON(ov, nv):
+			p.Changes = append(p.Changes, Change{Modified, path, ov, nv})
+			attrs[name] = nv
+		}
+	}
+	return attrs
+}
+
+// the new and changed elements of a plural.
+func (p *Patch) diffPlural(path string, old, _new []interface{}) []interface{} {
+	byId := make(map[string]map[string]interface{})
+	for _, elem := range old {
+		elem := elem.(map[string]interface{})
+		if id, ok := elementId(elem); ok {
+			byId[id] = elem
+		}
+	}
+	var elems []interface{}
+	seen := make(map[string]bool)
+	for _, elem := range _new {
+		elem := elem.(map[string]interface{})
+		id, ok := elementId(elem)
+		oelem, exists := byId[id]
+		if !ok || !exists {
+			p.Changes = append(p.Changes, Change{Added, path + "#new", nil, elem})
+			added := make(map[string]interface{}, len(elem))
+			for k, v := range elem {
+				if !ReadOnlyAttributes[k] {
+					added[k] = v
+				}
+			}
+			elems = append(elems, added)
+			continue
+		}
+		seen[id] = true
+		if attrs := p.diffObject(path+"#"+id+".", oelem, elem); len(attrs) > 0 {
+			attrs["id"] = elem["id"]
+			elems = append(elems, attrs)
+		}
+	}
+	for id, elem := range byId {
+		if !seen[id] {
+			p.Changes = append(p.Changes, Change{Removed, path + "#" + id, elem, nil})
+			p.Removed = append(p.Removed, path+"#"+id)
+		}
+	}
+	return elems
+}
+
+func elementId(elem map[string]interface{}) (string, bool) {
+	id, ok := elem["id"]
+	if !ok || id == nil {
+		return "", false
+	}
+	return fmt.Sprint(id), true
+}
+
+// true if v is an array of objects.
+func isPlural(v interface{}) bool {
+	elems, ok := v.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range elems {
+		if _, ok := elem.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func equalJSON(a, b interface{}) bool {
+	pa, erra := json.Marshal(a)
+	pb, errb := json.Marshal(b)
+	return erra == nil && errb == nil && string(pa) == string(pb)
+}
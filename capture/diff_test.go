@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var old, _new map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"id": 1, "uuid": "abc", "lastUpdated": "2013-05-21 10:00:00.0 +0000",
+		"givenName": "Bob", "familyName": "Smith", "email": "bob@example.com",
+		"primaryAddress": {"city": "Portland", "zip": "97201"},
+		"profiles": [
+			{"id": 10, "domain": "facebook.com", "url": "a"},
+			{"id": 11, "domain": "twitter.com", "url": "b"},
+			{"id": 12, "domain": "google.com", "url": "c"}
+		]
+	}`), &old)
+	json.Unmarshal([]byte(`{
+		"uuid": "def", "lastUpdated": "2026-10-18 10:00:00.0 +0000",
+		"givenName": "Robert", "email": "bob@example.com", "birthday": "1980-01-01",
+		"primaryAddress": {"city": "Seattle", "zip": "97201"},
+		"profiles": [
+			{"id": 10, "domain": "facebook.com", "url": "a"},
+			{"id": 11, "domain": "twitter.com", "url": "B"},
+			{"domain": "linkedin.com", "url": "d"}
+		]
+	}`), &_new)
+
+	p := Diff(old, _new)
+	expect := `{"birthday":"1980-01-01","givenName":"Robert","primaryAddress":{"city":"Seattle"},"profiles":[{"id":11,"url":"B"},{"domain":"linkedin.com","url":"d"}]}`
+	if got := jsonStringer(p.Attributes).String(); got != expect {
+		t.Errorf("unexpected attributes:\n%s\n%s", got, expect)
+	}
+	if len(p.Removed) != 1 || p.Removed[0] != "profiles#12" {
+		t.Errorf("unexpected removals: %v", p.Removed)
+	}
+	expectDiff := `+ birthday: "1980-01-01"
+~ givenName: "Bob" -> "Robert"
+~ primaryAddress.city: "Portland" -> "Seattle"
+~ profiles#11.url: "b" -> "B"
+- profiles#12: {"domain":"google.com","id":12,"url":"c"}
++ profiles#new: {"domain":"linkedin.com","url":"d"}`
+	if p.String() != expectDiff {
+		t.Errorf("unexpected diff:\n%s", p)
+	}
+
+	if p := Diff(old, old); !p.Empty() || p.Attributes != nil || p.Removed != nil {
+		t.Errorf("unexpected patch for identical entities: %#v", p)
+	}
+}
+
+// appended elements share a path and are listed in the order they were given.
+func TestDiffAppendOrder(t *testing.T) {
+	old := map[string]interface{}{"profiles": []interface{}{}}
+	var profiles []interface{}
+	var expect []string
+	for i := 0; i < 50; i++ {
+		domain := fmt.Sprintf("%d.example.com", i)
+		profiles = append(profiles, map[string]interface{}{"domain": domain})
+		expect = append(expect, fmt.Sprintf(`+ profiles#new: {"domain":%q}`, domain))
+	}
+	_new := map[string]interface{}{"profiles": profiles}
+	// other changes, in map order, are sorted around the appended elements.
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("%c%d", 'a'+i%26, i)
+		_new[name] = i
+	}
+	p := Diff(old, _new)
+	var got []string
+	for _, c := range p.Changes {
+		if c.Path == "profiles#new" {
+			got = append(got, c.String())
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("unexpected diff:\n%s", p)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// entity.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"encoding/json"
+)
+
+// the response of /entity.
+type EntityResult struct {
+	Result interface{} `json:"result"`
+}
+
+// the response of /entity.count.
+type CountResult struct {
+	TotalCount int `json:"total_count"`
+}
+
+// the response of /entity.find.
+type FindResult struct {
+	ResultCount int         `json:"result_count"`
+	Results     interface{} `json:"results"`
+}
+
+// retrieve the entity of type typeName identified by uuid and decode it into
+// out. params may contain additional parameters for /entity (e.g.
+// "attributes").
+func (client *Client) Entity(typeName, uuid string, params Params, out interface{}) error {
+	ps := Params{"type_name": typeName, "uuid": uuid}
+	for k, v := range params {
+		ps[k] = v
+	}
+	return client.executeInto(client.auth, client.verb("/entity", "GET"), "/entity", nil, ps, &EntityResult{out})
+}
+
+// count the entities of type typeName matching filter. an empty filter counts
+// all entities.
+func (client *Client) EntityCount(typeName string, filter string) (int, error) {
+	ps := Params{"type_name": typeName}
+	if filter != "" {
+		ps["filter"] = filter
+	}
+	var result CountResult
+	err := client.executeInto(client.auth, client.verb("/entity.count", "GET"), "/entity.count", nil, ps, &result)
+	return result.TotalCount, err
+}
+
+// find entities of type typeName and decode them into out, which should be a
+// pointer to a slice. params may contain additional parameters for
+// /entity.find (e.g. "filter", "max_results"). the number of results is
+// returned.
+func (client *Client) EntityFind(typeName string, params Params, out interface{}) (int, error) {
+	ps := Params{"type_name": typeName}
+	for k, v := range params {
+		ps[k] = v
+	}
+	result := FindResult{Results: out}
+	err := client.executeInto(client.auth, client.verb("/entity.find", "GET"), "/entity.find", nil, ps, &result)
+	return result.ResultCount, err
+}
+
+// find entities of type typeName, passing each to fn as it is read from the
+// response. params may contain additional parameters for /entity.find. the
+// number of results is returned. see ExecuteResults.
+func (client *Client) EntityFindEach(typeName string, params Params, fn func(entity json.RawMessage) error) (int, error) {
+	ps := Params{"type_name": typeName}
+	for k, v := range params {
+		ps[k] = v
+	}
+	resp, err := client.executeResults(client.auth, client.verb("/entity.find", "GET"), "/entity.find", nil, ps, fn)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Get("result_count").MustInt(), nil
+}
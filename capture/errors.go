@@ -0,0 +1,80 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// errors.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"fmt"
+)
+
+// an error code documented by Capture. an ErrorCode can be used as the target
+// of errors.Is to test for a RemoteError.
+//
+//	_, err := client.Execute("/entity", nil, params)
+//	if errors.Is(err, capture.ErrRecordNotFound) {
+//		// ...
+//	}
+type ErrorCode int
+
+const (
+	ErrMissingArgument          ErrorCode = 100
+	ErrInvalidArgument          ErrorCode = 200
+	ErrDuplicateArgument        ErrorCode = 201
+	ErrInvalidAuthMethod        ErrorCode = 202
+	ErrUnknownAttribute         ErrorCode = 223
+	ErrRecordNotFound           ErrorCode = 310
+	ErrUniqueViolation          ErrorCode = 360
+	ErrMissingRequiredAttribute ErrorCode = 361
+	ErrInvalidClientCredentials ErrorCode = 402
+	ErrAccessTokenExpired       ErrorCode = 414
+	ErrRateLimitExceeded        ErrorCode = 510
+	ErrAPIFeatureDisabled       ErrorCode = 540
+)
+
+// the "error" value Capture sends with each code.
+var errorKinds = map[ErrorCode]string{
+	ErrMissingArgument:          "missing_argument",
+	ErrInvalidArgument:          "invalid_argument",
+	ErrDuplicateArgument:        "duplicate_argument",
+	ErrInvalidAuthMethod:        "invalid_auth_method",
+	ErrUnknownAttribute:         "unknown_attribute",
+	ErrRecordNotFound:           "record_not_found",
+	ErrUniqueViolation:          "unique_violation",
+	ErrMissingRequiredAttribute: "missing_required_attribute",
+	ErrInvalidClientCredentials: "invalid_client_credentials",
+	ErrAccessTokenExpired:       "access_token_expired",
+	ErrRateLimitExceeded:        "rate_limit_exceeded",
+	ErrAPIFeatureDisabled:       "api_feature_disabled",
+}
+
+// the "error" value Capture sends with the code, or an empty string if the code
+// is not known.
+func (code ErrorCode) Kind() string {
+	return errorKinds[code]
+}
+
+func (code ErrorCode) Error() string {
+	if kind := code.Kind(); kind != "" {
+		return fmt.Sprintf("capture error %d (%s)", int(code), kind)
+	}
+	return fmt.Sprintf("capture error %d", int(code))
+}
+
+// an error is an ErrorCode if it is a RemoteError carrying that code. because
+// the kind of an error is more stable than its number, errors are matched by
+// kind when both are known.
+func (err RemoteError) Is(target error) bool {
+	switch target := target.(type) {
+	case ErrorCode:
+		if kind := target.Kind(); kind != "" && err.Kind != "" {
+			return err.Kind == kind
+		}
+		return err.Code == int(target)
+	case RemoteError:
+		return err.Code == target.Code && err.Kind == target.Kind
+	}
+	return false
+}
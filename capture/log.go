@@ -0,0 +1,181 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// log.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"github.com/bitly/go-simplejson"
+	"github.com/bmatsuo1/go-janrain"
+
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// a record of a single http request made by a Client. secrets in Params and
+// Header are redacted. a call that is retried after refreshing an access token
+// produces one event per request.
+type CallEvent struct {
+	Method    string        // the API method, with a leading slash
+	Verb      string        // the http method
+	Endpoint  string        // the request url without a query string
+	Params    url.Values    // see janrain.Redact
+	Header    http.Header   // see janrain.RedactHeader
+	Status    int           // the http status, zero if no response was read
+	Latency   time.Duration // time until the response was decoded
+	RequestId string        // the request_id returned by Capture, if any
+	ErrorKind string        // see ErrorKind; empty if the call succeeded
+	Err       error
+
+	body     []byte // the response body as received
+	redact   sync.Once
+	redacted []byte
+}
+
+// the response body, if any, with secrets redacted. see janrain.RedactJSON.
+// the body is redacted on the first call, so hooks that do not log bodies do
+// not pay for it.
+func (event *CallEvent) Body() []byte {
+	event.redact.Do(func() {
+		if event.body != nil {
+			// responses such as that of /oauth/token contain secrets.
+			event.redacted = []byte(janrain.RedactJSON(string(event.body)))
+		}
+	})
+	return event.redacted
+}
+
+// the structured form of an event. the response body is not included.
+func (event *CallEvent) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("method", event.Method),
+		slog.String("verb", event.Verb),
+		slog.String("endpoint", event.Endpoint),
+		slog.String("params", event.Params.Encode()),
+		slog.Any("header", event.Header),
+		slog.Int("status", event.Status),
+		slog.Duration("latency", event.Latency),
+	}
+	if event.RequestId != "" {
+		attrs = append(attrs, slog.String("request_id", event.RequestId))
+	}
+	if event.Err != nil {
+		attrs = append(attrs,
+			slog.String("error_kind", event.ErrorKind),
+			slog.String("error", event.Err.Error()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// a short description of the kind of error returned by a call. a RemoteError
+// is described by its Kind (e.g. "record_not_found"). errors from other
+// layers are described as "transport", "encoding", "content_type", "json" or
+// "error".
+func ErrorKind(err error) string {
+	var remote RemoteError
+	var transport HttpTransportError
+	var encoding EncodingError
+	var ctype *ContentTypeError
+	var decoder JsonDecoderError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &remote):
+		if remote.Kind != "" {
+			return remote.Kind
+		}
+		return "remote"
+	case errors.As(err, &encoding):
+		// decoding errors are reported wrapped in an HttpTransportError.
+		return "encoding"
+	case errors.As(err, &transport):
+		return "transport"
+	case errors.As(err, &ctype):
+		return "content_type"
+	case errors.As(err, &decoder):
+		return "json"
+	}
+	return "error"
+}
+
+// set a function called with an event after each http request made by the
+// client. fn must not modify the event. a nil fn disables logging.
+func (client *Client) SetLogHook(fn func(*CallEvent)) {
+	client.log = fn
+}
+
+// a log hook writing events to logger under the key "call". successful calls
+// are logged at slog.LevelInfo and failed calls at slog.LevelWarn. when logger
+// is enabled for slog.LevelDebug the response body is included as "body".
+//
+//	client.SetLogHook(capture.SlogHook(slog.Default()))
+func SlogHook(logger *slog.Logger) func(*CallEvent) {
+	return func(event *CallEvent) {
+		ctx := context.Background()
+		level := slog.LevelInfo
+		if event.Err != nil {
+			level = slog.LevelWarn
+		}
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+		attrs := []slog.Attr{slog.Any("call", event)}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			if body := event.Body(); body != nil {
+				attrs = append(attrs, slog.String("body", string(body)))
+			}
+		}
+		logger.LogAttrs(ctx, level, "capture "+event.Method, attrs...)
+	}
+}
+
+// wrap perform so that each request is reported to the client's log hook and
+// metrics.
+func (client *Client) observe(perform performFunc) performFunc {
+	if client.log == nil && client.metrics == nil {
+		return perform
+	}
+	return func(req *janrain.Request) (*HttpResponseData, *simplejson.Json, error) {
+		start := time.Now()
+		r, js, err := perform(req)
+		data := req.Data()
+		event := &CallEvent{
+			Method:    methodKey(data.Method),
+			Verb:      data.Verb,
+			Endpoint:  data.Endpoint,
+			Params:    data.Params,
+			Header:    janrain.RedactHeader(req.Header),
+			Latency:   time.Since(start),
+			ErrorKind: ErrorKind(err),
+			Err:       err,
+		}
+		resp := r
+		if resp == nil {
+			var rerr HttpResponse
+			if errors.As(err, &rerr) {
+				resp = rerr.HttpResponse()
+			}
+		}
+		if resp != nil {
+			event.Status = resp.StatusCode
+			event.body = resp.Body
+		}
+		if js != nil {
+			event.RequestId = js.Get("request_id").MustString()
+		}
+		if client.log != nil {
+			client.log(event)
+		}
+		if client.metrics != nil {
+			client.metrics.Call(client.app, event)
+		}
+		return r, js, err
+	}
+}
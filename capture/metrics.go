@@ -0,0 +1,43 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// metrics.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"time"
+)
+
+// receives measurements of the calls made by a Client. app is the label given
+// to SetMetrics, typically the name of an app in a config.Config. methods must
+// be safe for concurrent use. see package capture/metrics for an
+// implementation.
+type Metrics interface {
+	// called after each http request.
+	Call(app string, event *CallEvent)
+
+	// called before a call to method is retried. reason is the Kind of the
+	// error that caused the retry.
+	Retry(app, method, reason string)
+
+	// called after a caller waited d to stay under a rate limit before calling
+	// method.
+	RateLimitWait(app, method string, d time.Duration)
+}
+
+// set the metrics reported for calls made by the client. app labels the
+// client's measurements. a nil m disables metrics.
+func (client *Client) SetMetrics(app string, m Metrics) {
+	client.app = app
+	client.metrics = m
+}
+
+// report that the caller waited d to stay under a rate limit before calling
+// method. the wait is passed to the client's Metrics, if any.
+func (client *Client) RateLimitWait(method string, d time.Duration) {
+	if client.metrics != nil {
+		client.metrics.RateLimitWait(client.app, methodKey(method), d)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// metrics.go [created: Sun, 18 Oct 2026]
+
+/*
+Package metrics collects measurements of Capture API calls and exports them in
+the Prometheus text exposition format.
+
+	reg := metrics.New()
+	client := capture.NewClient("https://myapp.janraincapture.com", &creds)
+	client.SetMetrics("myapp", reg)
+	http.Handle("/metrics", reg)
+
+The following metrics are exported, labeled by app and API method.
+
+	capture_calls_total                    http requests made
+	capture_errors_total                   errors returned by Capture, by kind
+	capture_transport_errors_total         requests failing without a Capture error
+	capture_retries_total                  retried calls, by reason
+	capture_rate_limit_waits_total         waits to stay under a rate limit
+	capture_rate_limit_wait_seconds_total  time spent waiting
+	capture_call_duration_seconds          a histogram of request latency
+*/
+package metrics
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the upper bounds, in seconds, of latency histogram buckets used by New.
+var DefaultBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// the labels of a measurement.
+type labels struct {
+	app, method, extra string
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// a capture.Metrics that keeps measurements in memory for export.
+type Registry struct {
+	buckets []float64
+
+	mut       sync.Mutex
+	calls     map[labels]uint64
+	errors    map[labels]uint64 // extra is the error kind
+	transport map[labels]uint64
+	retries   map[labels]uint64 // extra is the reason
+	waits     map[labels]uint64
+	waited    map[labels]float64
+	latency   map[labels]*histogram
+}
+
+// construct a Registry using DefaultBuckets.
+func New() *Registry {
+	return NewBuckets(DefaultBuckets)
+}
+
+// construct a Registry whose latency histograms have the given bucket upper
+// bounds in seconds.
+func NewBuckets(buckets []float64) *Registry {
+	_buckets := append([]float64(nil), buckets...)
+	sort.Float64s(_buckets)
+	return &Registry{
+		buckets:   _buckets,
+		calls:     make(map[labels]uint64),
+		errors:    make(map[labels]uint64),
+		transport: make(map[labels]uint64),
+		retries:   make(map[labels]uint64),
+		waits:     make(map[labels]uint64),
+		waited:    make(map[labels]float64),
+		latency:   make(map[labels]*histogram),
+	}
+}
+
+var _ capture.Metrics = new(Registry)
+
+func (reg *Registry) Call(app string, event *capture.CallEvent) {
+	key := labels{app: app, method: event.Method}
+	reg.mut.Lock()
+	defer reg.mut.Unlock()
+	reg.calls[key]++
+	if event.Err != nil {
+		var remote capture.RemoteError
+		if errors.As(event.Err, &remote) {
+			reg.errors[labels{app, event.Method, event.ErrorKind}]++
+		} else {
+			reg.transport[key]++
+		}
+	}
+	h := reg.latency[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(reg.buckets))}
+		reg.latency[key] = h
+	}
+	secs := event.Latency.Seconds()
+	for i, le := range reg.buckets {
+		if secs <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += secs
+	h.count++
+}
+
+func (reg *Registry) Retry(app, method, reason string) {
+	reg.mut.Lock()
+	defer reg.mut.Unlock()
+	reg.retries[labels{app, method, reason}]++
+}
+
+func (reg *Registry) RateLimitWait(app, method string, d time.Duration) {
+	key := labels{app: app, method: method}
+	reg.mut.Lock()
+	defer reg.mut.Unlock()
+	reg.waits[key]++
+	reg.waited[key] += d.Seconds()
+}
+
+// write all measurements in the Prometheus text format.
+func (reg *Registry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	reg.mut.Lock()
+	reg.write(bw)
+	reg.mut.Unlock()
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// serve measurements to a Prometheus scraper.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	reg.WriteTo(w)
+}
+
+// must be called with reg.mut held.
+func (reg *Registry) write(w io.Writer) {
+	counter(w, "capture_calls_total", "HTTP requests made to the Capture API.", "", reg.calls)
+	counter(w, "capture_errors_total", "Errors returned by the Capture API.", "kind", reg.errors)
+	counter(w, "capture_transport_errors_total", "Requests that failed without a Capture API error.", "", reg.transport)
+	counter(w, "capture_retries_total", "Capture API calls that were retried.", "reason", reg.retries)
+	counter(w, "capture_rate_limit_waits_total", "Waits to stay under a Capture API rate limit.", "", reg.waits)
+
+	name := "capture_rate_limit_wait_seconds_total"
+	fmt.Fprintf(w, "# HELP %s Time spent waiting to stay under a Capture API rate limit.\n", name)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	keys := make([]labels, 0, len(reg.waited))
+	for key := range reg.waited {
+		keys = append(keys, key)
+	}
+	for _, key := range sorted(keys) {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, key.format(""), formatFloat(reg.waited[key]))
+	}
+
+	name = "capture_call_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of HTTP requests made to the Capture API.\n", name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	keys = make([]labels, 0, len(reg.latency))
+	for key := range reg.latency {
+		keys = append(keys, key)
+	}
+	for _, key := range sorted(keys) {
+		h := reg.latency[key]
+		var cumulative uint64
+		for i, le := range reg.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, key.format(""), formatFloat(le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, key.format(""), h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, key.format(""), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, key.format(""), h.count)
+	}
+}
+
+func counter(w io.Writer, name, help, extra string, values map[labels]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	keys := make([]labels, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	for _, key := range sorted(keys) {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, key.format(extra), values[key])
+	}
+}
+
+// the label pairs of a measurement. extra names the label holding key.extra,
+// if any.
+func (key labels) format(extra string) string {
+	s := fmt.Sprintf("app=\"%s\",method=\"%s\"", escape(key.app), escape(key.method))
+	if extra != "" {
+		s += fmt.Sprintf(",%s=\"%s\"", extra, escape(key.extra))
+	}
+	return s
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sorted(keys []labels) []labels {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.app != b.app {
+			return a.app < b.app
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.extra < b.extra
+	})
+	return keys
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
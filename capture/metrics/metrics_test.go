@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/bmatsuo1/go-janrain/capture"
+
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("uuid") == "missing" {
+			fmt.Fprint(w, `{"stat":"error","code":310,"error":"record_not_found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"stat":"ok"}`)
+	}))
+	defer server.Close()
+	reg := NewBuckets([]float64{60})
+	client := capture.NewClient(server.URL, nil)
+	client.SetMetrics("myapp", reg)
+
+	client.Execute("/entity", nil, capture.Params{"uuid": "abc"})
+	client.Execute("entity", nil, capture.Params{"uuid": "missing"})
+	client.RateLimitWait("entity", 2*time.Second)
+
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+	out := buf.String()
+	for _, line := range []string{
+		`capture_calls_total{app="myapp",method="/entity"} 2`,
+		`capture_errors_total{app="myapp",method="/entity",kind="record_not_found"} 1`,
+		`capture_rate_limit_wait_seconds_total{app="myapp",method="/entity"} 2`,
+		`capture_call_duration_seconds_bucket{app="myapp",method="/entity",le="60"} 2`,
+		`capture_call_duration_seconds_count{app="myapp",method="/entity"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q in output:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, `method="entity"`) {
+		t.Errorf("method labels were not normalized:\n%s", out)
+	}
+	if strings.Contains(out, "capture_transport_errors_total{") {
+		t.Errorf("unexpected transport errors:\n%s", out)
+	}
+}
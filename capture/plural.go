@@ -0,0 +1,77 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// plural.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"fmt"
+)
+
+// a plural attribute (e.g. "profiles") of an entity.
+//
+//	profiles := client.Plural("user", uuid, "profiles")
+//	err := profiles.Update(12, map[string]interface{}{"verified": true})
+type Plural struct {
+	client         *Client
+	typeName, uuid string
+	name           string
+}
+
+// a plural attribute of the entity of type typeName identified by uuid. name
+// is the attribute path of the plural.
+func (client *Client) Plural(typeName, uuid, name string) *Plural {
+	return &Plural{client, typeName, uuid, name}
+}
+
+// the attribute path of the element with the given id (e.g. "profiles#12").
+func (p *Plural) Path(id int64) string {
+	return fmt.Sprintf("%s#%d", p.name, id)
+}
+
+// decode the elements of the plural into out, which should be a pointer to a
+// slice.
+func (p *Plural) List(out interface{}) error {
+	return p.client.executeInto(p.client.auth, p.client.verb("/entity", "GET"), "/entity", nil, Params{
+		"type_name":      p.typeName,
+		"uuid":           p.uuid,
+		"attribute_name": p.name,
+	}, &EntityResult{out})
+}
+
+// add elements to the plural. elements must not have ids; Capture assigns
+// them.
+func (p *Plural) Append(elems ...interface{}) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	return p.update(p.name, elems)
+}
+
+// set attributes of the element with the given id. attributes not given are
+// unchanged.
+func (p *Plural) Update(id int64, attrs interface{}) error {
+	return p.update(p.Path(id), attrs)
+}
+
+// remove the element with the given id.
+func (p *Plural) Remove(id int64) error {
+	_, err := p.client.Execute("/entity.delete", nil, Params{
+		"type_name":      p.typeName,
+		"uuid":           p.uuid,
+		"attribute_name": p.Path(id),
+	})
+	return err
+}
+
+func (p *Plural) update(attributeName string, attrs interface{}) error {
+	_, err := p.client.Execute("/entity.update", nil, Params{
+		"type_name":      p.typeName,
+		"uuid":           p.uuid,
+		"attribute_name": attributeName,
+		"value":          attrs,
+	})
+	return err
+}
@@ -0,0 +1,52 @@
+package capture
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlural(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, fmt.Sprintf("%s %s %s %s", r.Method, r.URL.Path, r.FormValue("attribute_name"), r.FormValue("value")))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/entity" {
+			fmt.Fprint(w, `{"stat":"ok","result":[{"id":12,"domain":"example.com"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"stat":"ok"}`)
+	}))
+	defer server.Close()
+	profiles := NewClient(server.URL, nil).Plural("user", "abc", "profiles")
+
+	var elems []struct {
+		Id     int64  `json:"id"`
+		Domain string `json:"domain"`
+	}
+	if err := profiles.List(&elems); err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 1 || elems[0].Id != 12 {
+		t.Errorf("unexpected elements: %#v", elems)
+	}
+	if err := profiles.Append(map[string]string{"domain": "new.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.Update(12, map[string]string{"domain": "other.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.Remove(12); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"GET /entity profiles ",
+		`POST /entity.update profiles [{"domain":"new.com"}]`,
+		`POST /entity.update profiles#12 {"domain":"other.com"}`,
+		"POST /entity.delete profiles#12 ",
+	}
+	if fmt.Sprint(calls) != fmt.Sprint(expect) {
+		t.Errorf("unexpected calls:\n%q\n%q", calls, expect)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// precondition.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"fmt"
+	"time"
+)
+
+// the attribute checked by a Precondition that does not name one.
+const DefaultVersionAttribute = "lastUpdated"
+
+// a value an entity's attribute must have for a change to be made, typically
+// the lastUpdated timestamp of the entity as it was last read.
+//
+// Capture has no conditional writes, so the attribute is read and compared
+// before the change is sent. this narrows the window in which a concurrent
+// change can be overwritten but does not close it.
+type Precondition struct {
+	Attribute string      // an attribute path, DefaultVersionAttribute if empty
+	Value     interface{} // a time.Time is compared with the parsed timestamp
+}
+
+// an entity did not satisfy a Precondition. callers should read the entity
+// again and retry their change.
+type ConflictError struct {
+	TypeName, Uuid string
+	Attribute      string
+	Expected       interface{}
+	Actual         interface{}
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("conflicting change to %s %s: %s is %v, expected %v",
+		err.TypeName, err.Uuid, err.Attribute, jsonStringer(err.Actual), jsonStringer(err.Expected))
+}
+
+// set attributes of an entity with /entity.update. attributes not in value are
+// unchanged. if pre is not nil the update is only made if the entity satisfies
+// it, otherwise a *ConflictError is returned.
+func (client *Client) EntityUpdate(typeName, uuid string, value interface{}, pre *Precondition) error {
+	return client.entityWrite("/entity.update", typeName, uuid, value, pre)
+}
+
+// replace an entity with /entity.replace. attributes not in value are cleared.
+// if pre is not nil the entity is only replaced if it satisfies pre, otherwise
+// a *ConflictError is returned.
+func (client *Client) EntityReplace(typeName, uuid string, value interface{}, pre *Precondition) error {
+	return client.entityWrite("/entity.replace", typeName, uuid, value, pre)
+}
+
+func (client *Client) entityWrite(method, typeName, uuid string, value interface{}, pre *Precondition) error {
+	if pre != nil {
+		err := client.check(typeName, uuid, pre)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := client.Execute(method, nil, Params{
+		"type_name": typeName,
+		"uuid":      uuid,
+		"value":     value,
+	})
+	return err
+}
+
+// read the attribute of pre and compare it with the expected value.
+func (client *Client) check(typeName, uuid string, pre *Precondition) error {
+	attr := pre.Attribute
+	if attr == "" {
+		attr = DefaultVersionAttribute
+	}
+	var actual interface{}
+	err := client.Entity(typeName, uuid, Params{"attribute_name": attr}, &actual)
+	if err != nil {
+		return err
+	}
+	if !preconditionHolds(pre.Value, actual) {
+		return &ConflictError{
+			TypeName:  typeName,
+			Uuid:      uuid,
+			Attribute: attr,
+			Expected:  pre.Value,
+			Actual:    actual,
+		}
+	}
+	return nil
+}
+
+func preconditionHolds(expected, actual interface{}) bool {
+	if t, ok := expected.(time.Time); ok {
+		s, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		_t, err := Time(s)
+		return err == nil && _t.Equal(t)
+	}
+	return equalJSON(expected, actual)
+}
@@ -0,0 +1,51 @@
+package capture
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEntityUpdatePrecondition(t *testing.T) {
+	const lastUpdated = "2013-05-21 10:00:00.123456 +0000"
+	var updates int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/entity":
+			if r.FormValue("attribute_name") != "lastUpdated" {
+				t.Errorf("unexpected attribute: %q", r.FormValue("attribute_name"))
+			}
+			fmt.Fprintf(w, `{"stat":"ok","result":%q}`, lastUpdated)
+		case "/entity.update":
+			updates++
+			fmt.Fprint(w, `{"stat":"ok"}`)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, nil)
+	value := map[string]string{"givenName": "Bob"}
+
+	err := client.EntityUpdate("user", "abc", value, &Precondition{Value: "2013-05-21 09:00:00 +0000"})
+	if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if updates != 0 {
+		t.Errorf("entity was updated despite a conflict")
+	}
+
+	t0, _ := Time(lastUpdated)
+	err = client.EntityUpdate("user", "abc", value, &Precondition{Value: t0.In(time.FixedZone("", 3600))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.EntityUpdate("user", "abc", value, &Precondition{Value: lastUpdated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updates != 2 {
+		t.Errorf("unexpected number of updates: %d", updates)
+	}
+}
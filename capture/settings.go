@@ -0,0 +1,101 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// settings.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+// the settings of an app or of one of its API clients. app-wide settings are
+// the defaults for every client of the app.
+//
+//	settings := client.ClientSettings("myclientid")
+//	value, ok, err := settings.Get("password_recover_url")
+type Settings struct {
+	client   *Client
+	clientId string // empty for app-wide settings
+}
+
+// the app-wide default settings.
+func (client *Client) Settings() *Settings {
+	return &Settings{client: client}
+}
+
+// the settings of the API client identified by clientId.
+func (client *Client) ClientSettings(clientId string) *Settings {
+	return &Settings{client: client, clientId: clientId}
+}
+
+// the API client whose settings are managed, or an empty string for app-wide
+// settings.
+func (s *Settings) ClientId() string {
+	return s.clientId
+}
+
+// all settings. the items of a client include the app-wide settings it does
+// not override. Capture has no call listing only app-wide settings, so the
+// app-wide items also include any settings of the calling client.
+func (s *Settings) Items() (map[string]string, error) {
+	var resp struct {
+		Result map[string]string `json:"result"`
+	}
+	err := s.execute("/settings/items", s.client.verb("/settings/items", "GET"), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// the value of a setting. ok is false if the setting does not exist. the
+// value of a client's setting falls back to the app-wide setting. as with
+// Items, an app-wide value may be overridden by the calling client.
+func (s *Settings) Get(key string) (value string, ok bool, err error) {
+	var resp struct {
+		Result *string `json:"result"`
+	}
+	err = s.execute("/settings/get", s.client.verb("/settings/get", "GET"), Params{"key": key}, &resp)
+	if err != nil || resp.Result == nil {
+		return "", false, err
+	}
+	return *resp.Result, true, nil
+}
+
+// assign a setting. replaced is true if the setting already existed.
+func (s *Settings) Set(key, value string) (replaced bool, err error) {
+	var resp struct {
+		Result bool `json:"result"`
+	}
+	method := s.method("/settings/set")
+	err = s.execute(method, s.client.verb(method, "POST"), Params{"key": key, "value": value}, &resp)
+	return resp.Result, err
+}
+
+// remove a setting. deleted is false if the setting did not exist.
+func (s *Settings) Delete(key string) (deleted bool, err error) {
+	var resp struct {
+		Result bool `json:"result"`
+	}
+	method := s.method("/settings/delete")
+	err = s.execute(method, s.client.verb(method, "POST"), Params{"key": key}, &resp)
+	return resp.Result, err
+}
+
+// the method for a call that changes settings. app-wide settings are changed
+// through the "_default" variant (e.g. /settings/set_default).
+func (s *Settings) method(method string) string {
+	if s.clientId == "" {
+		return method + "_default"
+	}
+	return method
+}
+
+func (s *Settings) execute(method, verb string, params Params, out interface{}) error {
+	ps := Params{}
+	for k, v := range params {
+		ps[k] = v
+	}
+	if s.clientId != "" {
+		ps["for_client_id"] = s.clientId
+	}
+	return s.client.executeInto(s.client.auth, verb, method, nil, ps, out)
+}
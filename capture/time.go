@@ -0,0 +1,161 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// time.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// a date attribute. the zero value is null. DateValue can be used in structs
+// decoded from Capture responses, as a value in filters and as a database
+// column.
+//
+//	var user struct {
+//		Birthday capture.DateValue `json:"birthday"`
+//	}
+type DateValue struct {
+	Time  time.Time
+	Valid bool // false if the date is null
+}
+
+// a DateValue for t.
+func NewDate(t time.Time) DateValue {
+	return DateValue{t, true}
+}
+
+func (d DateValue) MarshalJSON() ([]byte, error) {
+	if !d.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(Datestamp(d.Time))
+}
+
+func (d *DateValue) UnmarshalJSON(p []byte) error {
+	var s *string
+	err := json.Unmarshal(p, &s)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		*d = DateValue{}
+		return nil
+	}
+	t, err := Date(*s)
+	if err != nil {
+		return err
+	}
+	*d = DateValue{t, true}
+	return nil
+}
+
+// a quoted datestamp, or null.
+func (d DateValue) FilterValue() string {
+	if !d.Valid {
+		return "null"
+	}
+	return fmt.Sprintf("'%s'", Datestamp(d.Time))
+}
+
+// a datestamp, or an empty string if the date is null.
+func (d DateValue) String() string {
+	if !d.Valid {
+		return ""
+	}
+	return Datestamp(d.Time)
+}
+
+// implements database/sql.Scanner for time.Time, datestamp and NULL values.
+func (d *DateValue) Scan(src interface{}) error {
+	t, valid, err := scan(src, Date)
+	if err != nil {
+		return err
+	}
+	d.Time, d.Valid = t, valid
+	return nil
+}
+
+// a dateTime attribute. the zero value is null. see DateValue.
+type TimeValue struct {
+	Time  time.Time
+	Valid bool // false if the time is null
+}
+
+// a TimeValue for t.
+func NewTime(t time.Time) TimeValue {
+	return TimeValue{t, true}
+}
+
+func (t TimeValue) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(Timestamp(t.Time))
+}
+
+// accepts any timestamp accepted by Time.
+func (t *TimeValue) UnmarshalJSON(p []byte) error {
+	var s *string
+	err := json.Unmarshal(p, &s)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		*t = TimeValue{}
+		return nil
+	}
+	_t, err := Time(*s)
+	if err != nil {
+		return err
+	}
+	*t = TimeValue{_t, true}
+	return nil
+}
+
+// a quoted timestamp, or null.
+func (t TimeValue) FilterValue() string {
+	if !t.Valid {
+		return "null"
+	}
+	return fmt.Sprintf("'%s'", Timestamp(t.Time))
+}
+
+// a timestamp, or an empty string if the time is null.
+func (t TimeValue) String() string {
+	if !t.Valid {
+		return ""
+	}
+	return Timestamp(t.Time)
+}
+
+// implements database/sql.Scanner for time.Time, timestamp and NULL values.
+func (t *TimeValue) Scan(src interface{}) error {
+	_t, valid, err := scan(src, Time)
+	if err != nil {
+		return err
+	}
+	t.Time, t.Valid = _t, valid
+	return nil
+}
+
+// convert a database value using parse for text.
+func scan(src interface{}, parse func(string) (time.Time, error)) (time.Time, bool, error) {
+	switch src := src.(type) {
+	case nil:
+		return time.Time{}, false, nil
+	case time.Time:
+		return src, true, nil
+	case string:
+		t, err := parse(src)
+		return t, err == nil, err
+	case []byte:
+		t, err := parse(string(src))
+		return t, err == nil, err
+	}
+	return time.Time{}, false, fmt.Errorf("cannot scan %T into a date or time", src)
+}
@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var (
+	_ sql.Scanner    = new(DateValue)
+	_ sql.Scanner    = new(TimeValue)
+	_ json.Marshaler = DateValue{}
+	_ json.Marshaler = TimeValue{}
+)
+
+func TestTime(t *testing.T) {
+	expect := time.Date(2013, 5, 21, 10, 30, 0, 0, time.UTC)
+	for _, timestamp := range []string{
+		"2013-05-21 10:30:00 +0000",
+		"2013-05-21 10:30:00.000000 +0000",
+		"2013-05-21 12:30:00.0 +0200",
+		"2013-05-21 10:30:00.0 +00:00",
+		"2013-05-21 10:30:00",
+		"2013-05-21 10:30:00.000",
+		"2013-05-21T10:30:00Z",
+	} {
+		_t, err := Time(timestamp)
+		if err != nil {
+			t.Errorf("%q: %v", timestamp, err)
+		} else if !_t.Equal(expect) {
+			t.Errorf("%q: unexpected time %v", timestamp, _t)
+		}
+	}
+	if _, err := Time("yesterday"); err == nil {
+		t.Errorf("invalid timestamp was parsed")
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	var user struct {
+		Birthday    DateValue `json:"birthday"`
+		LastLogin   TimeValue `json:"lastLogin"`
+		LastUpdated TimeValue `json:"lastUpdated"`
+	}
+	err := json.Unmarshal([]byte(`{"birthday":"1980-01-02","lastLogin":null,"lastUpdated":"2013-05-21 10:30:00"}`), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Birthday.Valid || user.Birthday.String() != "1980-01-02" {
+		t.Errorf("unexpected birthday: %#v", user.Birthday)
+	}
+	if user.LastLogin.Valid || user.LastLogin.FilterValue() != "null" {
+		t.Errorf("unexpected lastLogin: %#v", user.LastLogin)
+	}
+	if fv := user.LastUpdated.FilterValue(); fv != "'2013-05-21 10:30:00 +0000'" {
+		t.Errorf("unexpected filter value: %s", fv)
+	}
+	p, _ := json.Marshal(user)
+	if string(p) != `{"birthday":"1980-01-02","lastLogin":null,"lastUpdated":"2013-05-21 10:30:00 +0000"}` {
+		t.Errorf("unexpected json: %s", p)
+	}
+
+	var d DateValue
+	if err := d.Scan([]byte("1980-01-02")); err != nil || !d.Valid {
+		t.Errorf("unexpected scan: %#v %v", d, err)
+	}
+	if err := d.Scan(nil); err != nil || d.Valid {
+		t.Errorf("unexpected scan of NULL: %#v %v", d, err)
+	}
+}
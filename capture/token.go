@@ -0,0 +1,199 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// token.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// the default amount of time before expiration that a TokenSource refreshes
+// its access token.
+var DefaultRefreshLeeway = time.Minute
+
+// an access/refresh token pair issued by /oauth/token.
+type Token struct {
+	AccessToken  string    `json:"access_token" yaml:"access_token"`
+	RefreshToken string    `json:"refresh_token" yaml:"refresh_token"`
+	Expires      time.Time `json:"expires" yaml:"expires"`
+}
+
+// true if the token expires within leeway of now. tokens with a zero Expires
+// field are assumed to be valid until Capture says otherwise.
+func (token *Token) expiring(now time.Time, leeway time.Duration) bool {
+	return !token.Expires.IsZero() && !now.Add(leeway).Before(token.Expires)
+}
+
+// an Authorization that authorizes requests with an access token and uses its
+// refresh token to obtain a new access token when the current one expires.
+// a TokenSource is safe for use by multiple goroutines.
+type TokenSource struct {
+	// the client used to call /oauth/token.
+	Client *Client
+	// the credentials of the client that the tokens were issued to.
+	Credentials *ClientCredentials
+	// the amount of time before expiration to refresh the access token. if
+	// zero DefaultRefreshLeeway is used.
+	Leeway time.Duration
+	// called with each new token so that it may be persisted. OnRefresh is
+	// called after the TokenSource is unlocked and may call its methods. calls
+	// are not concurrent and are made in the order tokens were issued.
+	OnRefresh func(Token)
+
+	mut      sync.Mutex
+	token    Token
+	gen      int          // incremented by each refresh
+	inflight *refreshCall // the refresh in progress, if any
+
+	notifyMut sync.Mutex
+	notified  int // the gen of the last token passed to OnRefresh
+}
+
+// a call to /oauth/token shared by the goroutines waiting for it.
+type refreshCall struct {
+	done chan struct{} // closed when the call is complete
+	err  error
+}
+
+// construct a TokenSource from a previously issued token.
+func NewTokenSource(client *Client, creds *ClientCredentials, token Token) *TokenSource {
+	return &TokenSource{
+		Client:      client,
+		Credentials: creds,
+		token:       token,
+	}
+}
+
+// the current token.
+func (src *TokenSource) Token() Token {
+	src.mut.Lock()
+	defer src.mut.Unlock()
+	return src.token
+}
+
+// obtain a new access token regardless of the current token's expiration. if
+// a refresh is already in progress its result is used.
+func (src *TokenSource) Refresh() error {
+	_, err := src.refresh(func(Token) bool { return true })
+	return err
+}
+
+// adds an Authorization header containing the current access token,
+// refreshing it first if it is about to expire.
+func (src *TokenSource) Authorize(uri *url.URL, header http.Header, values url.Values) error {
+	leeway := src.Leeway
+	if leeway == 0 {
+		leeway = DefaultRefreshLeeway
+	}
+	token, err := src.refresh(func(token Token) bool {
+		return token.expiring(src.Client.now(), leeway)
+	})
+	if err != nil {
+		return err
+	}
+	return AccessToken(token.AccessToken).Authorize(uri, header, values)
+}
+
+// refresh the access token after Capture rejected a request authorized with
+// header as expired. if another goroutine already replaced the rejected token
+// it is not refreshed again.
+func (src *TokenSource) expire(header http.Header) error {
+	_, err := src.refresh(func(token Token) bool {
+		return header.Get("Authorization") == "OAuth "+token.AccessToken
+	})
+	return err
+}
+
+// pass the current token to OnRefresh if it has not been passed already. must
+// not be called with src.mut held.
+func (src *TokenSource) notify() {
+	if src.OnRefresh == nil {
+		return
+	}
+	src.notifyMut.Lock()
+	defer src.notifyMut.Unlock()
+	src.mut.Lock()
+	token, gen := src.token, src.gen
+	src.mut.Unlock()
+	if gen > src.notified {
+		src.notified = gen
+		src.OnRefresh(token)
+	}
+}
+
+// return the current token, refreshing it first if need reports that it must
+// be replaced. callers arriving while a refresh is in progress wait for its
+// result rather than starting another. src.mut is not held during the call to
+// /oauth/token, so a slow refresh does not block Token.
+func (src *TokenSource) refresh(need func(Token) bool) (Token, error) {
+	src.mut.Lock()
+	if call := src.inflight; call != nil {
+		src.mut.Unlock()
+		<-call.done
+		return src.Token(), call.err
+	}
+	if !need(src.token) {
+		defer src.mut.Unlock()
+		return src.token, nil
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	src.inflight = call
+	old := src.token
+	src.mut.Unlock()
+
+	token, err := src.fetch(old)
+
+	src.mut.Lock()
+	if err == nil {
+		src.token = token
+		src.gen++
+	}
+	token = src.token
+	src.inflight = nil
+	call.err = err
+	src.mut.Unlock()
+	close(call.done)
+	src.notify()
+	return token, err
+}
+
+// exchange the refresh token of old for a new token.
+func (src *TokenSource) fetch(old Token) (Token, error) {
+	if src.Client == nil || src.Credentials == nil {
+		return Token{}, fmt.Errorf("token source has no client credentials")
+	}
+	if old.RefreshToken == "" {
+		return Token{}, fmt.Errorf("token source has no refresh token")
+	}
+	now := src.Client.now()
+	// /oauth/token expects the client id and secret as parameters.
+	creds := (*ClientCredentialsSimple)(src.Credentials)
+	resp, err := src.Client.ExecuteAuth(creds, "/oauth/token", nil, Params{
+		"grant_type":    "refresh_token",
+		"refresh_token": old.RefreshToken,
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	token := Token{
+		AccessToken:  resp.Get("access_token").MustString(),
+		RefreshToken: resp.Get("refresh_token").MustString(),
+	}
+	if token.AccessToken == "" {
+		return Token{}, fmt.Errorf("no access token in /oauth/token response")
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = old.RefreshToken
+	}
+	if expiresIn := resp.Get("expires_in").MustInt(); expiresIn > 0 {
+		token.Expires = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
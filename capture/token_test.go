@@ -0,0 +1,219 @@
+package capture
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// a server issuing access tokens "token1", "token2", ... through /oauth/token
+// and rejecting calls to /entity not authorized with the latest one.
+type tokenServer struct {
+	*httptest.Server
+	refreshes int32
+	current   atomic.Value // the access token accepted by /entity
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	s := new(tokenServer)
+	s.current.Store("token0")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth/token":
+			if r.FormValue("client_id") != "id" || r.FormValue("client_secret") != "secret" {
+				t.Errorf("unexpected client credentials: %q", r.Form)
+			}
+			if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh" {
+				t.Errorf("unexpected refresh parameters: %q", r.Form)
+			}
+			n := atomic.AddInt32(&s.refreshes, 1)
+			token := fmt.Sprintf("token%d", n)
+			s.current.Store(token)
+			fmt.Fprintf(w, `{"stat":"ok","access_token":%q,"expires_in":3600}`, token)
+		case "/entity":
+			if r.Header.Get("Authorization") != "OAuth "+s.current.Load().(string) {
+				fmt.Fprint(w, `{"stat":"error","code":414,"error":"access_token_expired"}`)
+				return
+			}
+			fmt.Fprint(w, `{"stat":"ok","result":{}}`)
+		default:
+			t.Errorf("unexpected call: %s", r.URL.Path)
+		}
+	}))
+	return s
+}
+
+func newTestTokenSource(url string, now time.Time, expires time.Time) (*Client, *TokenSource) {
+	creds := &ClientCredentials{"id", "secret"}
+	client := NewClient(url, creds)
+	client.SetClock(ClockFunc(func() time.Time { return now }))
+	src := NewTokenSource(client, creds, Token{
+		AccessToken:  "token0",
+		RefreshToken: "refresh",
+		Expires:      expires,
+	})
+	return client, src
+}
+
+func TestTokenSourceAuthorize(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	client, src := newTestTokenSource(server.URL, now, now.Add(time.Hour))
+
+	_, err := client.ExecuteAuth(src, "/entity", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&server.refreshes); n != 0 {
+		t.Errorf("unexpected refreshes: %d", n)
+	}
+}
+
+func TestTokenSourceRefreshOnExpiry(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	client, src := newTestTokenSource(server.URL, now, now.Add(30*time.Second))
+	var refreshed []Token
+	src.OnRefresh = func(token Token) {
+		// must not deadlock.
+		if src.Token() != token {
+			t.Errorf("OnRefresh called with a stale token")
+		}
+		refreshed = append(refreshed, token)
+	}
+
+	_, err := client.ExecuteAuth(src, "/entity", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Token{"token1", "refresh", now.Add(time.Hour)}
+	if token := src.Token(); token != want {
+		t.Errorf("unexpected token: %#v", token)
+	}
+	if len(refreshed) != 1 || refreshed[0] != want {
+		t.Errorf("unexpected OnRefresh calls: %#v", refreshed)
+	}
+}
+
+func TestTokenSourceExpiredRetry(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+	server.current.Store("revoked")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	client, src := newTestTokenSource(server.URL, now, time.Time{})
+
+	_, err := client.ExecuteAuth(src, "/entity", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := src.Token(); token.AccessToken != "token1" {
+		t.Errorf("unexpected token: %#v", token)
+	}
+	if n := atomic.LoadInt32(&server.refreshes); n != 1 {
+		t.Errorf("unexpected refreshes: %d", n)
+	}
+}
+
+func TestTokenSourceSingleFlight(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		current string
+		expires time.Duration
+	}{
+		{"expiring", "token0", time.Second},
+		{"rejected", "revoked", 0},
+	} {
+		server := newTokenServer(t)
+		server.current.Store(test.current)
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		var expires time.Time
+		if test.expires > 0 {
+			expires = now.Add(test.expires)
+		}
+		client, src := newTestTokenSource(server.URL, now, expires)
+		var notified int32
+		src.OnRefresh = func(Token) { atomic.AddInt32(&notified, 1) }
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.ExecuteAuth(src, "/entity", nil, nil)
+				if err != nil {
+					t.Errorf("%s: %v", test.name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		server.Close()
+		if n := atomic.LoadInt32(&server.refreshes); n != 1 {
+			t.Errorf("%s: unexpected refreshes: %d", test.name, n)
+		}
+		if n := atomic.LoadInt32(&notified); n != 1 {
+			t.Errorf("%s: unexpected OnRefresh calls: %d", test.name, n)
+		}
+	}
+}
+
+func TestTokenSourceNoRefreshToken(t *testing.T) {
+	src := NewTokenSource(NewClient("http://localhost", nil), &ClientCredentials{"id", "secret"}, Token{AccessToken: "token0"})
+	if err := src.Refresh(); err == nil {
+		t.Errorf("refreshed without a refresh token")
+	}
+}
+
+// a refresh in progress does not hold the token source's lock, and callers
+// arriving during it share its result.
+func TestTokenSourceSlowRefresh(t *testing.T) {
+	release := make(chan struct{})
+	var refreshes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&refreshes, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"stat":"ok","access_token":"token1","expires_in":3600}`)
+	}))
+	defer server.Close()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	_, src := newTestTokenSource(server.URL, now, now)
+
+	errs := make(chan error)
+	for i := 0; i < 5; i++ {
+		// callers arriving after the refresh find the token valid.
+		go func() { errs <- src.Authorize(new(url.URL), make(http.Header), make(url.Values)) }()
+	}
+	for atomic.LoadInt32(&refreshes) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	token := make(chan Token)
+	go func() { token <- src.Token() }()
+	select {
+	case tok := <-token:
+		if tok.AccessToken != "token0" {
+			t.Errorf("unexpected token during refresh: %#v", tok)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Token blocked by a refresh in progress")
+	}
+	close(release)
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Errorf("unexpected refreshes: %d", n)
+	}
+	if tok := src.Token(); tok.AccessToken != "token1" {
+		t.Errorf("unexpected token: %#v", tok)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trace.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"github.com/bitly/go-simplejson"
+
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// starts spans around API calls. the interface is small enough to be
+// implemented by an adapter for OpenTelemetry or another tracing library.
+type Tracer interface {
+	// start a span named name as a child of any span in ctx. the returned
+	// context carries the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// a unit of traced work.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// a Tracer that also implements Propagator adds the trace context of each
+// span to the headers of requests sent to Capture (e.g. "traceparent").
+type Propagator interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+// the attributes set on spans.
+const (
+	AttrMethod     = "capture.method"
+	AttrEntityType = "capture.entity_type"
+	AttrAppDomain  = "capture.app_domain"
+	AttrRequestId  = "capture.request_id"
+	AttrErrorKind  = "capture.error_kind"
+	AttrHttpMethod = "http.method"
+	AttrHttpStatus = "http.status_code"
+)
+
+// set the tracer that starts a span for each API call made by the client. a
+// call retried after refreshing an access token is a single span. a nil
+// tracer disables tracing.
+func (client *Client) SetTracer(tracer Tracer) {
+	client.tracer = tracer
+}
+
+// a copy of the client that makes calls in ctx. spans started for its calls
+// are children of any span in ctx and its requests are canceled with ctx.
+func (client *Client) WithContext(ctx context.Context) *Client {
+	_client := *client
+	_client.ctx = ctx
+	return &_client
+}
+
+// the context calls are made in.
+func (client *Client) context() context.Context {
+	if client.ctx == nil {
+		return context.Background()
+	}
+	return client.ctx
+}
+
+// start a span for a call, returning the call's context. the returned
+// function ends the span with the results of the call.
+func (client *Client) startSpan(verb, method string, params Params) (context.Context, func(*HttpResponseData, *simplejson.Json, error)) {
+	ctx := client.context()
+	if client.tracer == nil {
+		return ctx, func(*HttpResponseData, *simplejson.Json, error) {}
+	}
+	method = methodKey(method)
+	ctx, span := client.tracer.Start(ctx, "capture "+method)
+	span.SetAttribute(AttrMethod, method)
+	span.SetAttribute(AttrHttpMethod, verb)
+	if typeName, ok := params["type_name"].(string); ok {
+		span.SetAttribute(AttrEntityType, typeName)
+	}
+	if uri, err := url.Parse(client.baseurl); err == nil {
+		span.SetAttribute(AttrAppDomain, uri.Host)
+	}
+	return ctx, func(r *HttpResponseData, js *simplejson.Json, err error) {
+		defer span.End()
+		var remote RemoteError
+		switch {
+		case js != nil && js.Get("request_id").MustString() != "":
+			span.SetAttribute(AttrRequestId, js.Get("request_id").MustString())
+		case errors.As(err, &remote) && remote.RequestId != "":
+			span.SetAttribute(AttrRequestId, remote.RequestId)
+		}
+		if r == nil {
+			var rerr HttpResponse
+			if errors.As(err, &rerr) {
+				r = rerr.HttpResponse()
+			}
+		}
+		if r != nil {
+			span.SetAttribute(AttrHttpStatus, r.StatusCode)
+		}
+		if err != nil {
+			span.SetAttribute(AttrErrorKind, ErrorKind(err))
+			span.RecordError(err)
+		}
+	}
+}
+
+// add the trace context of ctx to header if the client's tracer supports it.
+func (client *Client) inject(ctx context.Context, header http.Header) {
+	if p, ok := client.tracer.(Propagator); ok {
+		p.Inject(ctx, header)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// verify.go [created: Sun, 18 Oct 2026]
+
+package capture
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// a request whose signature could not be verified.
+type SignatureError struct {
+	ClientId string
+	Reason   string
+}
+
+func (err SignatureError) Error() string {
+	if err.ClientId == "" {
+		return fmt.Sprintf("invalid signature: %s", err.Reason)
+	}
+	return fmt.Sprintf("invalid signature for client %q: %s", err.ClientId, err.Reason)
+}
+
+// a signed request from a client id that is not known to the verifier.
+type UnknownClientError struct {
+	ClientId string
+}
+
+func (err UnknownClientError) Error() string {
+	return fmt.Sprintf("unknown client %q", err.ClientId)
+}
+
+// a signed request with a Date too far from the verifier's clock.
+type ClockSkewError struct {
+	Date time.Time
+	Skew time.Duration
+}
+
+func (err ClockSkewError) Error() string {
+	return fmt.Sprintf("request date %v differs from the current time by more than %v",
+		err.Date.Format(signatureDateFormat), err.Skew)
+}
+
+// verify the signature of a request authorized by ClientCredentials. lookup
+// returns the secret for a client id. the request Date must be within skew of
+// the current time, a non-positive skew disables the check. the request body
+// is restored after being read so that req may still be forwarded.
+func VerifySignature(req *http.Request, lookup func(id string) (secret string, ok bool), skew time.Duration) error {
+	v := &Verifier{Lookup: lookup, Skew: skew}
+	return v.Verify(req)
+}
+
+// verifies the signatures of requests authorized by ClientCredentials.
+type Verifier struct {
+	// returns the secret for a client id.
+	Lookup func(id string) (secret string, ok bool)
+	// the maximum difference between a request's Date and the current time.
+	// a non-positive Skew disables the check.
+	Skew time.Duration
+	// the source of the current time. if nil SystemClock is used.
+	Clock Clock
+	// the encoding clients sign parameters with. only signatures made with
+	// this encoding are accepted. the zero value is SignRaw.
+	Encoding SignatureEncoding
+}
+
+// verify the signature of req. see VerifySignature.
+func (v *Verifier) Verify(req *http.Request) error {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Signature ") {
+		return SignatureError{Reason: "missing signature"}
+	}
+	idsig := strings.SplitN(strings.TrimPrefix(auth, "Signature "), ":", 2)
+	if len(idsig) != 2 || idsig[0] == "" || idsig[1] == "" {
+		return SignatureError{Reason: "malformed authorization header"}
+	}
+	id, sig := idsig[0], idsig[1]
+
+	timestamp := req.Header.Get("Date")
+	date, err := time.Parse(signatureDateFormat, timestamp)
+	if err != nil {
+		return SignatureError{id, "malformed date header"}
+	}
+	if v.Skew > 0 {
+		clock := v.Clock
+		if clock == nil {
+			clock = SystemClock
+		}
+		diff := clock.Now().Sub(date)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > v.Skew {
+			return ClockSkewError{date, v.Skew}
+		}
+	}
+
+	secret, ok := v.Lookup(id)
+	if !ok {
+		return UnknownClientError{id}
+	}
+
+	err = parseFormRestoreBody(req)
+	if err != nil {
+		return SignatureError{id, fmt.Sprintf("unreadable parameters: %v", err)}
+	}
+	expect, err := v.Encoding.signature(secret, req.URL.Path, timestamp, req.Form)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(sig), []byte(expect)) {
+		return SignatureError{id, "signature mismatch"}
+	}
+	return nil
+}
+
+func parseFormRestoreBody(req *http.Request) error {
+	if req.Body == nil {
+		return req.ParseForm()
+	}
+	p, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(p))
+	err = req.ParseForm()
+	req.Body = ioutil.NopCloser(bytes.NewReader(p))
+	return err
+}
@@ -0,0 +1,141 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// encoding.go [created: Sun, 18 Oct 2026]
+
+package janrain
+
+import (
+	"github.com/andybalholm/brotli"
+
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// constructs a reader that decodes data with a Content-Encoding.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+var decoders = struct {
+	sync.RWMutex
+	m map[string]Decoder
+}{m: map[string]Decoder{
+	"gzip":    decodeGzip,
+	"deflate": decodeDeflate,
+	"br":      decodeBrotli,
+}}
+
+// register a Decoder for a Content-Encoding, replacing any existing decoder
+// for the encoding. registered encodings are advertised in the
+// Accept-Encoding header of requests. gzip, deflate and br are registered by
+// default.
+func RegisterEncoding(name string, dec Decoder) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	decoders.m[strings.ToLower(name)] = dec
+}
+
+// stop accepting a Content-Encoding.
+func UnregisterEncoding(name string) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	delete(decoders.m, strings.ToLower(name))
+}
+
+// the value of the Accept-Encoding header sent with requests.
+func AcceptEncoding() string {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	names := make([]string, 0, len(decoders.m))
+	for name := range decoders.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func decoder(name string) Decoder {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	return decoders.m[name]
+}
+
+// decode r according to a Content-Encoding header value. encodings listed in
+// the header are removed in reverse order of application. the name of an
+// encoding that could not be decoded is returned with the error.
+func decodeContent(r io.Reader, encoding string) (io.ReadCloser, string, error) {
+	var names []string
+	for _, name := range strings.Split(encoding, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && name != "identity" {
+			names = append(names, name)
+		}
+	}
+
+	rc := ioutil.NopCloser(r)
+	closers := make(multiCloser, 0, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		dec := decoder(names[i])
+		if dec == nil {
+			closers.Close()
+			return nil, names[i], UnknownEncoding
+		}
+		next, err := dec(rc)
+		if err != nil {
+			closers.Close()
+			return nil, names[i], err
+		}
+		closers = append(closers, next)
+		rc = next
+	}
+	return readCloser{rc, closers}, "", nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// closes decoders from the outermost in.
+type multiCloser []io.Closer
+
+func (cs multiCloser) Close() error {
+	var err error
+	for i := len(cs) - 1; i >= 0; i-- {
+		_err := cs[i].Close()
+		if err == nil {
+			err = _err
+		}
+	}
+	return err
+}
+
+func decodeGzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// servers disagree about whether "deflate" means a zlib stream (RFC 1950) or
+// raw deflate data (RFC 1951). both are accepted.
+func decodeDeflate(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && isZlibHeader(header) {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+func isZlibHeader(p []byte) bool {
+	return p[0]&0x0f == 8 && (uint16(p[0])<<8|uint16(p[1]))%31 == 0
+}
+
+func decodeBrotli(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(brotli.NewReader(r)), nil
+}
@@ -0,0 +1,98 @@
+package janrain
+
+import (
+	"github.com/andybalholm/brotli"
+
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func encodedResponse(encoding string, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Encoding": {encoding}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func compress(t *testing.T, body string, fn func(io.Writer) io.WriteCloser) []byte {
+	buf := new(bytes.Buffer)
+	w := fn(buf)
+	_, err := io.WriteString(w, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadResponseEncoding(t *testing.T) {
+	const body = `{"stat":"ok"}`
+	for _, test := range []struct {
+		encoding string
+		fn       func(io.Writer) io.WriteCloser
+	}{
+		{"gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+		{"deflate", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }},
+		{"deflate", func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}},
+		{"br", func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }},
+	} {
+		resp := encodedResponse(test.encoding, compress(t, body, test.fn))
+		r, err := ReadResponse(resp)
+		if err != nil {
+			t.Errorf("%s: %v", test.encoding, err)
+			continue
+		}
+		if string(r.Body) != body {
+			t.Errorf("%s: unexpected body %q", test.encoding, r.Body)
+		}
+	}
+
+	_, err := ReadResponse(encodedResponse("compress", []byte(body)))
+	if eerr, ok := err.(EncodingError); !ok || eerr.Err != UnknownEncoding || string(eerr.Body) != body {
+		t.Errorf("unexpected error for unknown encoding: %v", err)
+	}
+}
+
+func TestDecodeResponseUnknownEncoding(t *testing.T) {
+	const body = `{"stat":"ok"}`
+	resp := encodedResponse("zstd", []byte(body))
+	resp.Header.Set("Content-Type", "application/json")
+	_, _, err := DecodeResponse(resp)
+	if _, ok := err.(HttpTransportError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var eerr EncodingError
+	if !errors.As(err, &eerr) || eerr.Encoding != "zstd" || !errors.Is(err, UnknownEncoding) {
+		t.Errorf("unexpected cause for unknown encoding: %v", err)
+	}
+	if r := err.(HttpTransportError).HttpResponse(); r == nil || string(r.Body) != body {
+		t.Errorf("unexpected error response: %v", r)
+	}
+	if strings.Contains(AcceptEncoding(), "zstd") {
+		t.Errorf("unknown encoding accepted: %q", AcceptEncoding())
+	}
+
+	RegisterEncoding("zstd", func(r io.Reader) (io.ReadCloser, error) { return ioutil.NopCloser(r), nil })
+	defer UnregisterEncoding("zstd")
+	resp = encodedResponse("zstd", []byte(body))
+	resp.Header.Set("Content-Type", "application/json")
+	_, js, err := DecodeResponse(resp)
+	if err != nil || js.Get("stat").MustString() != "ok" {
+		t.Errorf("registered encoding not decoded: %v", err)
+	}
+}
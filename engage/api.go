@@ -0,0 +1,181 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// api.go [created: Sun, 18 Oct 2026]
+
+package engage
+
+import (
+	"github.com/bitly/go-simplejson"
+)
+
+// a user's name as normalized by Engage.
+type Name struct {
+	Formatted       string `json:"formatted,omitempty"`
+	FamilyName      string `json:"familyName,omitempty"`
+	GivenName       string `json:"givenName,omitempty"`
+	MiddleName      string `json:"middleName,omitempty"`
+	HonorificPrefix string `json:"honorificPrefix,omitempty"`
+	HonorificSuffix string `json:"honorificSuffix,omitempty"`
+}
+
+// a user's postal address as normalized by Engage.
+type Address struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"streetAddress,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postalCode,omitempty"`
+	Country       string `json:"country,omitempty"`
+}
+
+// a user profile normalized by Engage from the identity provider's data.
+type Profile struct {
+	Identifier        string   `json:"identifier"`
+	ProviderName      string   `json:"providerName"`
+	PrimaryKey        string   `json:"primaryKey,omitempty"`
+	DisplayName       string   `json:"displayName,omitempty"`
+	PreferredUsername string   `json:"preferredUsername,omitempty"`
+	Name              *Name    `json:"name,omitempty"`
+	Gender            string   `json:"gender,omitempty"`
+	Birthday          string   `json:"birthday,omitempty"`
+	UTCOffset         string   `json:"utcOffset,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	VerifiedEmail     string   `json:"verifiedEmail,omitempty"`
+	URL               string   `json:"url,omitempty"`
+	PhoneNumber       string   `json:"phoneNumber,omitempty"`
+	Photo             string   `json:"photo,omitempty"`
+	Address           *Address `json:"address,omitempty"`
+}
+
+// the result of an auth_info call.
+type AuthInfo struct {
+	Profile Profile
+	// the complete response, including any extended profile data.
+	Response *simplejson.Json
+}
+
+// exchange a sign-in token for the user's profile. if extended is true the
+// response includes extended profile data (for applications that have it).
+func (client *Client) AuthInfo(token string, extended bool) (*AuthInfo, error) {
+	resp, err := client.Execute("/auth_info", Params{
+		"token":    token,
+		"extended": extended,
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := &AuthInfo{Response: resp}
+	err = decode(resp.Get("profile"), &info.Profile)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// a contact's plural field value (such as an email address).
+type ContactValue struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
+
+// a Portable Contacts entry returned by get_contacts.
+type Contact struct {
+	DisplayName  string         `json:"displayName,omitempty"`
+	Emails       []ContactValue `json:"emails,omitempty"`
+	PhoneNumbers []ContactValue `json:"phoneNumbers,omitempty"`
+}
+
+// retrieve the contact list of the user with the given identifier.
+func (client *Client) GetContacts(identifier string) ([]Contact, error) {
+	resp, err := client.Execute("/get_contacts", Params{"identifier": identifier})
+	if err != nil {
+		return nil, err
+	}
+	var contacts []Contact
+	err = decode(resp.Get("response").Get("entry"), &contacts)
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// map an identifier to a primary key. if overwrite is false an identifier
+// already mapped to another key results in an error.
+func (client *Client) Map(identifier, primaryKey string, overwrite bool) error {
+	_, err := client.Execute("/map", Params{
+		"identifier": identifier,
+		"primaryKey": primaryKey,
+		"overwrite":  overwrite,
+	})
+	return err
+}
+
+// remove the mapping between an identifier and a primary key. if unlink is
+// true the application is also deauthorized with the provider (where
+// supported).
+func (client *Client) Unmap(identifier, primaryKey string, unlink bool) error {
+	_, err := client.Execute("/unmap", Params{
+		"identifier": identifier,
+		"primaryKey": primaryKey,
+		"unlink":     unlink,
+	})
+	return err
+}
+
+// remove all identifiers mapped to a primary key.
+func (client *Client) UnmapAll(primaryKey string, unlink bool) error {
+	_, err := client.Execute("/unmap", Params{
+		"all_identifiers": true,
+		"primaryKey":      primaryKey,
+		"unlink":          unlink,
+	})
+	return err
+}
+
+// the identifiers mapped to a primary key.
+func (client *Client) Mappings(primaryKey string) ([]string, error) {
+	resp, err := client.Execute("/mappings", Params{"primaryKey": primaryKey})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Get("identifiers").StringArray()
+}
+
+// set the status message of the user on the identity provider.
+func (client *Client) SetStatus(identifier, status string) error {
+	_, err := client.Execute("/set_status", Params{
+		"identifier": identifier,
+		"status":     status,
+	})
+	return err
+}
+
+// a link displayed with a published activity.
+type ActionLink struct {
+	Text string `json:"text"`
+	Href string `json:"href"`
+}
+
+// an activity published to a user's activity stream.
+type Activity struct {
+	Action               string       `json:"action"`
+	URL                  string       `json:"url"`
+	UserGeneratedContent string       `json:"user_generated_content,omitempty"`
+	Title                string       `json:"title,omitempty"`
+	Description          string       `json:"description,omitempty"`
+	ActionLinks          []ActionLink `json:"action_links,omitempty"`
+	Media                interface{}  `json:"media,omitempty"`
+	Properties           interface{}  `json:"properties,omitempty"`
+}
+
+// publish an activity to the activity stream of the user with the given
+// identifier.
+func (client *Client) Activity(identifier string, activity *Activity) error {
+	_, err := client.Execute("/activity", Params{
+		"identifier": identifier,
+		"activity":   activity,
+	})
+	return err
+}
@@ -0,0 +1,138 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// engage.go [created: Sun, 18 Oct 2026]
+
+/*
+A lightly wrapped http client library for accessing the Janrain Engage (social
+login) API.
+
+Authentication
+
+After a user signs in through the Engage widget the application receives a
+token which can be exchanged for the user's normalized profile.
+
+	client := engage.NewClient(engage.DefaultBaseURL, "myapikey")
+	info, err := client.AuthInfo(req.FormValue("token"), false)
+	if err != nil {
+		// ...
+	}
+	fmt.Println(info.Profile.Identifier, info.Profile.DisplayName)
+
+Mapping identifiers
+
+Provider identifiers can be mapped to the application's own primary keys.
+
+	err := client.Map(info.Profile.Identifier, user.Id, false)
+	ids, err := client.Mappings(user.Id)
+
+Raw calls
+
+API calls without a typed wrapper can be made using Execute.
+
+	resp, err := client.Execute("/get_user_data", engage.Params{
+		"identifier": identifier,
+	})
+
+*/
+package engage
+
+import (
+	"github.com/bitly/go-simplejson"
+	"github.com/bmatsuo1/go-janrain"
+
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// the base url of the Engage API.
+const DefaultBaseURL = "https://rpxnow.com/api/v2"
+
+// an error returned by Engage.
+type RemoteError struct {
+	Code     int
+	Message  string
+	Response *simplejson.Json
+	*janrain.HttpResponseData
+	*janrain.HttpRequestData
+}
+
+func (err RemoteError) HttpResponse() *janrain.HttpResponseData {
+	return err.HttpResponseData
+}
+
+func (err RemoteError) HttpRequest() *janrain.HttpRequestData {
+	return err.HttpRequestData
+}
+
+func (err RemoteError) Error() string {
+	return fmt.Sprintf("[%d] %s", err.Code, err.Message)
+}
+
+// a janrain.ErrorFunc for Engage responses.
+func remoteError(req *janrain.HttpRequestData, r *janrain.HttpResponseData, js *simplejson.Json) error {
+	return RemoteError{
+		Code:             js.Get("err").Get("code").MustInt(),
+		Message:          js.Get("err").Get("msg").MustString(),
+		Response:         js,
+		HttpResponseData: r,
+		HttpRequestData:  req,
+	}
+}
+
+// API request parameters. values that are not strings are JSON encoded.
+type Params = janrain.Params
+
+// an Engage API client.
+type Client struct {
+	baseurl string
+	apikey  string
+	http    *http.Client
+}
+
+// construct a new API client for the application with the given API key.
+func NewClient(baseurl, apikey string) *Client {
+	return &Client{
+		baseurl: strings.TrimSuffix(baseurl, "/"),
+		apikey:  apikey,
+		http:    new(http.Client),
+	}
+}
+
+// the http client used to send requests.
+func (client *Client) HttpClient() *http.Client {
+	return client.http
+}
+
+// set the http client used to send requests, e.g. to set a timeout or use a
+// custom transport.
+func (client *Client) SetHttpClient(c *http.Client) {
+	client.http = c
+}
+
+// execute an API call. the API key and response format are added to params.
+func (client *Client) Execute(method string, params Params) (*simplejson.Json, error) {
+	req, err := janrain.NewRequest("POST", client.baseurl, method, nil, params)
+	if err != nil {
+		return nil, err
+	}
+	req.Values.Set("apiKey", client.apikey)
+	req.Values.Set("format", "json")
+	_, js, err := janrain.Perform(client.http, req, remoteError)
+	if err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+// decode a field of an API response into v.
+func decode(js *simplejson.Json, v interface{}) error {
+	p, err := js.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(p, v)
+}
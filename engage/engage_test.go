@@ -0,0 +1,222 @@
+package engage
+
+import (
+	"github.com/bmatsuo1/go-janrain"
+
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// a server responding to each call with body and recording the last request.
+type testServer struct {
+	*httptest.Server
+	path string
+	form url.Values
+}
+
+func newTestServer(body string) *testServer {
+	s := new(testServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.path, s.form = r.URL.Path, r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	return s
+}
+
+// check the call made and the parameters sent. the apiKey and format are
+// expected in every call.
+func (s *testServer) check(t *testing.T, path string, params map[string]string) {
+	if s.path != path {
+		t.Errorf("unexpected path: %q", s.path)
+	}
+	expect := url.Values{"apiKey": {"myapikey"}, "format": {"json"}}
+	for k, v := range params {
+		expect.Set(k, v)
+	}
+	if !reflect.DeepEqual(s.form, expect) {
+		t.Errorf("%s: unexpected params: %q", path, s.form)
+	}
+}
+
+func TestAuthInfo(t *testing.T) {
+	server := newTestServer(`{"stat":"ok","profile":{
+		"identifier":"https://example.com/bob",
+		"providerName":"Example",
+		"displayName":"Bob",
+		"name":{"givenName":"Bob","familyName":"Smith"},
+		"address":{"locality":"Springfield"}
+	},"merged_poco":{"id":"bob"}}`)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+
+	info, err := client.AuthInfo("mytoken", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.check(t, "/auth_info", map[string]string{"token": "mytoken", "extended": "true"})
+	expect := Profile{
+		Identifier:   "https://example.com/bob",
+		ProviderName: "Example",
+		DisplayName:  "Bob",
+		Name:         &Name{GivenName: "Bob", FamilyName: "Smith"},
+		Address:      &Address{Locality: "Springfield"},
+	}
+	if !reflect.DeepEqual(info.Profile, expect) {
+		t.Errorf("unexpected profile: %#v", info.Profile)
+	}
+	if id := info.Response.Get("merged_poco").Get("id").MustString(); id != "bob" {
+		t.Errorf("extended data missing from response: %q", id)
+	}
+}
+
+func TestGetContacts(t *testing.T) {
+	server := newTestServer(`{"stat":"ok","response":{"entry":[
+		{"displayName":"Alice","emails":[{"type":"home","value":"alice@example.com"}]},
+		{"displayName":"Carol","phoneNumbers":[{"value":"555-0100"}]}
+	]}}`)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+
+	contacts, err := client.GetContacts("https://example.com/bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.check(t, "/get_contacts", map[string]string{"identifier": "https://example.com/bob"})
+	expect := []Contact{
+		{DisplayName: "Alice", Emails: []ContactValue{{"home", "alice@example.com"}}},
+		{DisplayName: "Carol", PhoneNumbers: []ContactValue{{"", "555-0100"}}},
+	}
+	if !reflect.DeepEqual(contacts, expect) {
+		t.Errorf("unexpected contacts: %#v", contacts)
+	}
+}
+
+func TestMappings(t *testing.T) {
+	server := newTestServer(`{"stat":"ok","identifiers":["https://example.com/bob","https://example.org/bob"]}`)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+
+	ids, err := client.Mappings("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.check(t, "/mappings", map[string]string{"primaryKey": "42"})
+	if !reflect.DeepEqual(ids, []string{"https://example.com/bob", "https://example.org/bob"}) {
+		t.Errorf("unexpected identifiers: %q", ids)
+	}
+}
+
+func TestCalls(t *testing.T) {
+	server := newTestServer(`{"stat":"ok"}`)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+
+	activity := &Activity{
+		Action:      "posted a comment",
+		URL:         "https://example.com/post/1",
+		ActionLinks: []ActionLink{{"Reply", "https://example.com/post/1#reply"}},
+	}
+	p, _ := json.Marshal(activity)
+	for _, test := range []struct {
+		call   func() error
+		path   string
+		params map[string]string
+	}{
+		{
+			func() error { return client.Map("https://example.com/bob", "42", false) },
+			"/map",
+			map[string]string{"identifier": "https://example.com/bob", "primaryKey": "42", "overwrite": "false"},
+		},
+		{
+			func() error { return client.Unmap("https://example.com/bob", "42", true) },
+			"/unmap",
+			map[string]string{"identifier": "https://example.com/bob", "primaryKey": "42", "unlink": "true"},
+		},
+		{
+			func() error { return client.UnmapAll("42", false) },
+			"/unmap",
+			map[string]string{"all_identifiers": "true", "primaryKey": "42", "unlink": "false"},
+		},
+		{
+			func() error { return client.SetStatus("https://example.com/bob", "hello") },
+			"/set_status",
+			map[string]string{"identifier": "https://example.com/bob", "status": "hello"},
+		},
+		{
+			func() error { return client.Activity("https://example.com/bob", activity) },
+			"/activity",
+			map[string]string{"identifier": "https://example.com/bob", "activity": string(p)},
+		},
+	} {
+		err := test.call()
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		server.check(t, test.path, test.params)
+	}
+}
+
+func TestRemoteError(t *testing.T) {
+	const body = `{"stat":"fail","err":{"code":2,"msg":"Data not found"}}`
+	server := newTestServer(body)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+
+	_, err := client.Mappings("42")
+	rerr, ok := err.(RemoteError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rerr.Code != 2 || rerr.Message != "Data not found" {
+		t.Errorf("unexpected error: %#v", rerr)
+	}
+	if string(rerr.HttpResponse().Body) != body {
+		t.Errorf("unexpected error body: %q", rerr.HttpResponse().Body)
+	}
+	req := rerr.HttpRequest()
+	if req == nil || req.Method != "/mappings" {
+		t.Fatalf("unexpected error request: %#v", req)
+	}
+	if key := req.Params.Get("apiKey"); key != janrain.Redacted {
+		t.Errorf("api key not redacted: %q", key)
+	}
+	if err.Error() != "[2] Data not found" {
+		t.Errorf("unexpected error message: %q", err)
+	}
+
+	err = client.Map("https://example.com/bob", "42", false)
+	if _, ok := err.(RemoteError); !ok {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return fn(req) }
+
+func TestSetHttpClient(t *testing.T) {
+	server := newTestServer(`{"stat":"ok","identifiers":[]}`)
+	defer server.Close()
+	client := NewClient(server.URL, "myapikey")
+	var sent int
+	client.SetHttpClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return http.DefaultTransport.RoundTrip(req)
+	})})
+
+	_, err := client.Mappings("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Errorf("request not sent through the http client: %d", sent)
+	}
+}
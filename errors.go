@@ -0,0 +1,307 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// errors.go [created: Sun, 18 Oct 2026]
+
+package janrain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// an error in http communication. the request and, if it was received but
+// could not be read, the response are available through HttpRequest and
+// HttpResponse.
+type HttpTransportError struct {
+	Err error
+}
+
+func (err HttpTransportError) Error() string {
+	return err.Err.Error()
+}
+
+func (err HttpTransportError) Unwrap() error {
+	return err.Err
+}
+
+func (err HttpTransportError) HttpResponse() *HttpResponseData {
+	if cause, ok := err.Err.(transportCause); ok {
+		return cause.r
+	}
+	return nil
+}
+
+func (err HttpTransportError) HttpRequest() *HttpRequestData {
+	if cause, ok := err.Err.(transportCause); ok {
+		return cause.req
+	}
+	return nil
+}
+
+// the cause of an HttpTransportError along with the request and response it
+// occurred in.
+type transportCause struct {
+	err error
+	req *HttpRequestData
+	r   *HttpResponseData
+}
+
+func (cause transportCause) Error() string {
+	return cause.err.Error()
+}
+
+func (cause transportCause) Unwrap() error {
+	return cause.err
+}
+
+func transportError(err error, req *HttpRequestData, r *HttpResponseData) HttpTransportError {
+	if cause, ok := err.(transportCause); ok {
+		err = cause.err
+	}
+	return HttpTransportError{transportCause{err, req, r}}
+}
+
+// an error decoding a JSON response from the API.
+type JsonDecoderError struct {
+	*HttpResponseData
+	Err error
+}
+
+func (err JsonDecoderError) Error() string {
+	return err.Err.Error()
+}
+
+func (err JsonDecoderError) Unwrap() error {
+	return err.Err
+}
+
+func (err JsonDecoderError) HttpResponse() *HttpResponseData {
+	return err.HttpResponseData
+}
+
+func (err JsonDecoderError) HttpRequest() *HttpRequestData {
+	return err.HttpResponseData.request()
+}
+
+// an error decoding the Content-Encoding of a response.
+type EncodingError struct {
+	Encoding string
+	Err      error
+	*HttpResponseData
+}
+
+func (err EncodingError) Error() string {
+	return fmt.Sprintf("error decoding %s data: %v", err.Encoding, err.Err)
+}
+
+func (err EncodingError) Unwrap() error {
+	return err.Err
+}
+
+func (err EncodingError) HttpResponse() *HttpResponseData {
+	return err.HttpResponseData
+}
+
+func (err EncodingError) HttpRequest() *HttpRequestData {
+	return err.HttpResponseData.request()
+}
+
+var UnknownEncoding = fmt.Errorf("unknown encoding")
+
+// an unexpected content type returned by the API.
+type ContentTypeError struct {
+	*HttpResponseData
+}
+
+func (err *ContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content-type %q", err.Header.Get("Content-Type"))
+}
+
+func (err *ContentTypeError) HttpResponse() *HttpResponseData {
+	return err.HttpResponseData
+}
+
+func (err *ContentTypeError) HttpRequest() *HttpRequestData {
+	return err.HttpResponseData.request()
+}
+
+// errors that carry the http response that caused them.
+type HttpResponse interface {
+	HttpResponse() *HttpResponseData
+}
+
+type HttpResponseData struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Request    *HttpRequestData // the API call, if known
+}
+
+func (r *HttpResponseData) request() *HttpRequestData {
+	if r == nil {
+		return nil
+	}
+	return r.Request
+}
+
+func (r *HttpResponseData) String() string {
+	return fmt.Sprintf("[%d] %q", r.StatusCode, r.Body)
+}
+
+// errors that carry the API call that caused them.
+type HttpRequest interface {
+	HttpRequest() *HttpRequestData
+}
+
+// a description of an API call. sensitive parameters are redacted.
+type HttpRequestData struct {
+	Verb     string     // the http method
+	Endpoint string     // the request url without a query string
+	Method   string     // the API method
+	Params   url.Values // see Redact
+}
+
+// the text replacing redacted values.
+const Redacted = "REDACTED"
+
+// true if the named JSON object key (or header) holds a secret.
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	if name == "apikey" {
+		return true
+	}
+	for _, s := range []string{"secret", "password", "token"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// true if the named parameter holds a secret. an OAuth authorization "code"
+// is a secret parameter, unlike the error "code" of a response.
+func sensitiveParam(name string) bool {
+	return strings.ToLower(name) == "code" || sensitive(name)
+}
+
+// a copy of values with the values of sensitive parameters (secrets,
+// passwords, tokens and keys) replaced by Redacted. parameters holding JSON
+// objects have sensitive keys redacted at any depth.
+func Redact(values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for k, vs := range values {
+		_vs := make([]string, len(vs))
+		for i, v := range vs {
+			if sensitiveParam(k) {
+				_vs[i] = Redacted
+			} else {
+				_vs[i] = RedactJSON(v)
+			}
+		}
+		redacted[k] = _vs
+	}
+	return redacted
+}
+
+// a copy of header with credentials replaced by Redacted. the scheme of an
+// Authorization header is kept so the kind of authorization is still visible.
+func RedactHeader(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for k, vs := range header {
+		_vs := make([]string, len(vs))
+		for i, v := range vs {
+			switch {
+			case k == "Authorization" || k == "Proxy-Authorization":
+				if j := strings.IndexByte(v, ' '); j > 0 {
+					_vs[i] = v[:j] + " " + Redacted
+				} else {
+					_vs[i] = Redacted
+				}
+			case k == "Cookie" || k == "Set-Cookie" || sensitive(k):
+				_vs[i] = Redacted
+			default:
+				_vs[i] = v
+			}
+		}
+		redacted[k] = _vs
+	}
+	return redacted
+}
+
+// v with the values of sensitive keys replaced by Redacted if it is a JSON
+// object or array. other values, and JSON without sensitive keys, are returned
+// unchanged. numbers in redacted JSON are kept exact but keys are sorted.
+func RedactJSON(v string) string {
+	trimmed := strings.TrimSpace(v)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return v
+	}
+	// numbers are kept exact, large ids would otherwise lose precision.
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var js interface{}
+	if dec.Decode(&js) != nil || dec.More() {
+		return v
+	}
+	if !redactValue(js) {
+		return v
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(js) != nil {
+		return Redacted
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redact sensitive keys in a decoded JSON value. returns true if anything was
+// redacted.
+func redactValue(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			if sensitive(k) {
+				v[k] = Redacted
+				redacted = true
+			} else if redactValue(v[k]) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			if redactValue(v[i]) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// attach the request that produced an error of a type defined by this package.
+func withRequest(err error, req *HttpRequestData) error {
+	switch e := err.(type) {
+	case HttpTransportError:
+		return transportError(e.Err, req, e.HttpResponse())
+	case JsonDecoderError:
+		setRequest(e.HttpResponseData, req)
+	case EncodingError:
+		setRequest(e.HttpResponseData, req)
+	case *ContentTypeError:
+		setRequest(e.HttpResponseData, req)
+	}
+	return err
+}
+
+func setRequest(r *HttpResponseData, req *HttpRequestData) {
+	if r != nil {
+		r.Request = req
+	}
+}
@@ -0,0 +1,38 @@
+package janrain
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	values := url.Values{
+		"type_name":     {"user"},
+		"client_secret": {"shh"},
+		"access_token":  {"abc123"},
+		"attributes":    {`{"email":"a@example.com","password":"hunter2"}`},
+	}
+	redacted := Redact(values)
+	for _, k := range []string{"client_secret", "access_token"} {
+		if redacted.Get(k) != Redacted {
+			t.Errorf("%s was not redacted: %q", k, redacted.Get(k))
+		}
+	}
+	if redacted.Get("type_name") != "user" {
+		t.Errorf("type_name was redacted: %q", redacted.Get("type_name"))
+	}
+	if attrs := redacted.Get("attributes"); attrs != `{"email":"a@example.com","password":"REDACTED"}` {
+		t.Errorf("unexpected attributes: %q", attrs)
+	}
+	if values.Get("client_secret") != "shh" {
+		t.Errorf("original values were modified")
+	}
+
+	// an OAuth authorization code is a secret, the code of an error is not.
+	if code := Redact(url.Values{"code": {"abc"}}).Get("code"); code != Redacted {
+		t.Errorf("code was not redacted: %q", code)
+	}
+	if body := RedactJSON(`{"code":310,"stat":"error"}`); body != `{"code":310,"stat":"error"}` {
+		t.Errorf("error code was redacted: %q", body)
+	}
+}
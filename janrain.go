@@ -4,6 +4,68 @@
 
 // Filename: janrain.go (Created: Tue, 21 May 2013)
 
-// Package janrain does ....
+/*
+Package janrain contains the transport shared by clients of Janrain's APIs.
+
+Janrain APIs (Capture, Engage, Backplane, Federate) accept form encoded
+parameters and respond with a JSON envelope whose "stat" field is "ok" when a
+call succeeds. This package builds and authorizes requests, decompresses and
+decodes responses, and types the errors that can occur along the way. API
+specific packages supply their own Authorization types and construct their own
+errors from failed response envelopes.
+
+	req, err := janrain.NewRequest("POST", baseurl, "/entity.count", nil, janrain.Params{
+		"type_name": "user",
+	})
+	if err != nil {
+		// ...
+	}
+	err = req.Authorize(auth)
+	if err != nil {
+		// ...
+	}
+	r, js, err := janrain.Perform(http.DefaultClient, req, newAPIError)
+
+See the packages
+
+	github.com/bmatsuo1/go-janrain/capture
+	github.com/bmatsuo1/go-janrain/engage
+
+for API clients.
+*/
 package janrain
 
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type Authorization interface {
+	// adds credentials to the url, header, and form values to permit the request.
+	Authorize(*url.URL, http.Header, url.Values) error
+}
+
+// API request parameters. values that are not strings are JSON encoded.
+type Params map[string]interface{}
+
+// the form encoding of ps.
+func (ps Params) Values() (url.Values, error) {
+	vals := make(url.Values, len(ps))
+	for k, v := range ps {
+		val, ok := v.(string)
+		if !ok {
+			p, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			val = string(p)
+		}
+		vals.Set(k, val)
+	}
+	return vals, nil
+}
+
+func (ps Params) Set(key string, value interface{}) {
+	ps[key] = value
+}
@@ -0,0 +1,380 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// transport.go [created: Sun, 18 Oct 2026]
+
+package janrain
+
+import (
+	"github.com/bitly/go-simplejson"
+
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// an API call that has not been sent.
+type Request struct {
+	Verb   string // the http method
+	Method string // the API method
+	URL    *url.URL
+	Header http.Header
+	Values url.Values
+
+	// the context of the http request. nil means context.Background.
+	Context context.Context
+}
+
+// construct a request for an API method relative to baseurl. header is copied
+// and params are form encoded.
+func NewRequest(verb, baseurl, method string, header http.Header, params Params) (*Request, error) {
+	endpoint := baseurl
+	if !strings.HasPrefix(method, "/") {
+		endpoint += "/"
+	}
+	endpoint += method
+	uri, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	_header := make(http.Header, len(header))
+	for k, v := range header {
+		_header[k] = v
+	}
+
+	values, err := params.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		Verb:   verb,
+		Method: method,
+		URL:    uri,
+		Header: _header,
+		Values: values,
+	}
+	return req, nil
+}
+
+// add credentials to the request. a nil auth leaves the request unchanged.
+func (req *Request) Authorize(auth Authorization) error {
+	if auth == nil {
+		return nil
+	}
+	return auth.Authorize(req.URL, req.Header, req.Values)
+}
+
+// construct the http request. POST requests send values as a form encoded
+// body, requests with other methods send them in the url's query string.
+func (req *Request) HttpRequest() (*http.Request, error) {
+	var body io.Reader
+	uri := *req.URL
+	if req.Verb == "POST" {
+		body = strings.NewReader(req.Values.Encode())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else if len(req.Values) > 0 {
+		query := uri.Query()
+		for k, v := range req.Values {
+			query[k] = append(query[k], v...)
+		}
+		uri.RawQuery = query.Encode()
+	}
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hreq, err := http.NewRequestWithContext(ctx, req.Verb, uri.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Header {
+		hreq.Header[k] = v
+	}
+	hreq.Header.Set("Accept-Encoding", AcceptEncoding())
+	return hreq, nil
+}
+
+// a description of the request with sensitive parameters redacted.
+func (req *Request) Data() *HttpRequestData {
+	endpoint := *req.URL
+	endpoint.RawQuery = ""
+	return &HttpRequestData{
+		Verb:     req.Verb,
+		Endpoint: endpoint.String(),
+		Method:   req.Method,
+		Params:   Redact(req.Values),
+	}
+}
+
+// constructs an API specific error from a response whose "stat" is not "ok".
+// r contains the response body as it was received.
+type ErrorFunc func(req *HttpRequestData, r *HttpResponseData, js *simplejson.Json) error
+
+// send a request and decode its response envelope. a response whose "stat" is
+// not "ok" produces the error returned by errfn. errors produced by this
+// package carry a description of req.
+func Perform(client *http.Client, req *Request, errfn ErrorFunc) (*HttpResponseData, *simplejson.Json, error) {
+	hreq, err := req.HttpRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(hreq)
+	if err != nil {
+		return nil, nil, transportError(err, req.Data(), nil)
+	}
+	r, js, err := DecodeResponse(resp)
+	if err != nil {
+		return r, nil, withRequest(err, req.Data())
+	}
+	if js.Get("stat").MustString() != "ok" {
+		return r, js, errfn(req.Data(), r, js)
+	}
+	return r, js, nil
+}
+
+func ReadResponse(resp *http.Response) (*HttpResponseData, error) {
+	defer resp.Body.Close()
+
+	rd, err := ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+
+	p, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &HttpResponseData{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       p,
+	}
+	return r, err
+}
+
+// the body of resp decoded according to its Content-Encoding. closing the
+// returned reader does not close resp.Body. an encoding without a registered
+// Decoder results in an EncodingError with the Err UnknownEncoding.
+func ResponseBody(resp *http.Response) (io.ReadCloser, error) {
+	rd, encoding, err := decodeContent(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err == UnknownEncoding {
+		p, _ := ioutil.ReadAll(resp.Body)
+		return nil, EncodingError{Encoding: encoding, Err: err, HttpResponseData: &HttpResponseData{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       p,
+		}}
+	}
+	if err != nil {
+		return nil, EncodingError{Encoding: encoding, Err: err, HttpResponseData: &HttpResponseData{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+		}}
+	}
+	return rd, nil
+}
+
+func contentType(resp *http.Response) string {
+	v := resp.Header.Get("Content-Type")
+	return strings.TrimFunc(strings.SplitN(v, ";", 2)[0], unicode.IsSpace)
+}
+
+// send a request and decode the response envelope, passing the elements of
+// the array named key to fn one at a time as they are read. the response body
+// is never held in memory in its entirety. the returned envelope contains all
+// fields other than key. the body of the returned HttpResponseData, and of any
+// error, is the raw body as received with the elements of key removed. if fn
+// returns an error the response is abandoned and the error is returned.
+func PerformStream(client *http.Client, req *Request, key string, fn func(json.RawMessage) error, errfn ErrorFunc) (*HttpResponseData, *simplejson.Json, error) {
+	r, js, err := performStream(client, req, key, fn, errfn)
+	if err != nil {
+		err = withRequest(err, req.Data())
+	}
+	return r, js, err
+}
+
+func performStream(client *http.Client, req *Request, key string, fn func(json.RawMessage) error, errfn ErrorFunc) (*HttpResponseData, *simplejson.Json, error) {
+	hreq, err := req.HttpRequest()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(hreq)
+	if err != nil {
+		return nil, nil, HttpTransportError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch mime := contentType(resp); mime {
+	case "application/json", "text/json":
+	default:
+		r, err := ReadResponse(resp)
+		if err != nil {
+			return nil, nil, readError(resp, err)
+		}
+		return r, nil, &ContentTypeError{HttpResponseData: r}
+	}
+
+	rd, err := ResponseBody(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rd.Close()
+
+	body := new(streamBody)
+	err = decodeStream(json.NewDecoder(io.TeeReader(rd, body)), key, body, fn)
+	r := &HttpResponseData{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body.p,
+	}
+	if err != nil {
+		if cberr, ok := err.(streamCallbackError); ok {
+			return r, nil, cberr.err
+		}
+		return r, nil, JsonDecoderError{HttpResponseData: r, Err: err}
+	}
+	js := new(simplejson.Json)
+	err = json.Unmarshal(r.Body, js)
+	if err != nil {
+		return r, nil, JsonDecoderError{HttpResponseData: r, Err: err}
+	}
+	if js.Get("stat").MustString() != "ok" {
+		return r, js, errfn(req.Data(), r, js)
+	}
+	return r, js, nil
+}
+
+// distinguishes errors returned by a PerformStream callback from decoding
+// errors.
+type streamCallbackError struct {
+	err error
+}
+
+func (err streamCallbackError) Error() string {
+	return err.err.Error()
+}
+
+// the bytes of a streamed response body less the elements already streamed.
+type streamBody struct {
+	p       []byte
+	dropped int64 // bytes removed from p
+}
+
+func (body *streamBody) Write(p []byte) (int, error) {
+	body.p = append(body.p, p...)
+	return len(p), nil
+}
+
+// remove the bytes of the body from index i up to decoder offset end.
+func (body *streamBody) drop(i int, end int64) {
+	j := int(end - body.dropped)
+	body.p = append(body.p[:i], body.p[j:]...)
+	body.dropped += int64(j - i)
+}
+
+func decodeStream(dec *json.Decoder, key string, body *streamBody, fn func(json.RawMessage) error) error {
+	err := expectDelim(dec, '{')
+	if err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		if name != key {
+			var raw json.RawMessage
+			err = dec.Decode(&raw)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return fmt.Errorf("field %q is not an array", key)
+		}
+		start := int(dec.InputOffset() - body.dropped)
+		for dec.More() {
+			var elem json.RawMessage
+			err = dec.Decode(&elem)
+			if err != nil {
+				return err
+			}
+			body.drop(start, dec.InputOffset())
+			err = fn(elem)
+			if err != nil {
+				return streamCallbackError{err}
+			}
+		}
+		err = expectDelim(dec, ']')
+		if err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v but found %v", delim, tok)
+	}
+	return nil
+}
+
+// read a JSON API response and decode its body. DecodeResponse does not
+// inspect the response for an API error. the returned error, if any, is an
+// HttpTransportError, JsonDecoderError, or *ContentTypeError.
+func DecodeResponse(resp *http.Response) (*HttpResponseData, *simplejson.Json, error) {
+	r, err := ReadResponse(resp)
+	if err != nil {
+		return nil, nil, readError(resp, err)
+	}
+	switch mime := contentType(resp); mime {
+	case "application/json", "text/json":
+		js := new(simplejson.Json)
+		err := json.Unmarshal(r.Body, js)
+		if err != nil {
+			return r, nil, JsonDecoderError{HttpResponseData: r, Err: err}
+		}
+		return r, js, nil
+	default:
+		return r, nil, &ContentTypeError{HttpResponseData: r}
+	}
+}
+
+// an error reading the body of resp. the response status and header are
+// retained along with the body if it was read.
+func readError(resp *http.Response, err error) error {
+	r := &HttpResponseData{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	if eerr, ok := err.(EncodingError); ok {
+		r = eerr.HttpResponseData
+	}
+	return transportError(fmt.Errorf("unable to read http response: %w", err), nil, r)
+}